@@ -0,0 +1,204 @@
+// Saving shootout results, and comparing them against an earlier run
+// to catch solvers that have gotten worse.
+
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+
+	"github.com/spf13/viper"
+)
+
+type runRecord struct {
+	Seed    int64  `json:"seed"`
+	Outcome string `json:"outcome"`
+	Steps   int    `json:"steps"`
+}
+
+type cellRecord struct {
+	Maze   string      `json:"maze"`
+	Solver string      `json:"solver"`
+	Runs   []runRecord `json:"runs"`
+}
+
+type shootoutRecord struct {
+	Seed     int64        `json:"seed"`
+	Times    int          `json:"times"`
+	Width    int          `json:"width"`
+	Height   int          `json:"height"`
+	MaxSteps int          `json:"max_steps"`
+	Cells    []cellRecord `json:"cells"`
+}
+
+func newRecord(gens []namedGen, solvers []namedSolver, seed int64, times int, table [][]score) *shootoutRecord {
+	rec := &shootoutRecord{
+		Seed:     seed,
+		Times:    times,
+		Width:    viper.GetInt("width"),
+		Height:   viper.GetInt("height"),
+		MaxSteps: viper.GetInt("max-steps"),
+	}
+	for i := range table {
+		for j := range table[i] {
+			cell := cellRecord{Maze: gens[i].name, Solver: solvers[j].name}
+			for k, r := range table[i][j].runs {
+				cell.Runs = append(cell.Runs, runRecord{
+					Seed:    seed + int64(k),
+					Outcome: r.outcome.String(),
+					Steps:   r.steps,
+				})
+			}
+			rec.Cells = append(rec.Cells, cell)
+		}
+	}
+	return rec
+}
+
+func loadRecord(path string) (*shootoutRecord, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	rec := &shootoutRecord{}
+	if err := json.Unmarshal(contents, rec); err != nil {
+		return nil, fmt.Errorf("reading baseline %s: %v", path, err)
+	}
+	return rec, nil
+}
+
+func (rec *shootoutRecord) save(path string) error {
+	contents, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, contents, 0644)
+}
+
+// A baseline is only comparable if it was run on the same size of maze
+// with the same step limit, otherwise the same seeds give different mazes.
+func (rec *shootoutRecord) compatible() error {
+	settings := []struct {
+		name      string
+		base, now int
+	}{
+		{"width", rec.Width, viper.GetInt("width")},
+		{"height", rec.Height, viper.GetInt("height")},
+		{"max-steps", rec.MaxSteps, viper.GetInt("max-steps")},
+	}
+	for _, s := range settings {
+		if s.base != s.now {
+			return fmt.Errorf("baseline was run with %s %d, not %d", s.name, s.base, s.now)
+		}
+	}
+	return nil
+}
+
+type regression struct {
+	maze, solver string
+	reason       string
+}
+
+func (r regression) String() string {
+	return fmt.Sprintf("%s, %s: %s", r.maze, r.solver, r.reason)
+}
+
+// Compare each maze/solver pair against the baseline, run by run.
+// A pair has regressed if more runs newly fail than newly succeed, or if
+// the steps taken on runs solved in both are significantly higher.
+func compareRecords(base, now *shootoutRecord) []regression {
+	var regressions []regression
+	for _, cell := range now.Cells {
+		baseCell, ok := base.cell(cell.Maze, cell.Solver)
+		if !ok {
+			continue
+		}
+
+		baseRuns := make(map[int64]runRecord, len(baseCell.Runs))
+		for _, r := range baseCell.Runs {
+			baseRuns[r.Seed] = r
+		}
+
+		var diffs []float64
+		newFails, newSolves := 0, 0
+		for _, r := range cell.Runs {
+			b, ok := baseRuns[r.Seed]
+			if !ok {
+				continue
+			}
+			wasSolved, isSolved := b.Outcome == solved.String(), r.Outcome == solved.String()
+			switch {
+			case wasSolved && isSolved:
+				diffs = append(diffs, float64(r.Steps-b.Steps))
+			case wasSolved:
+				newFails++
+			case isSolved:
+				newSolves++
+			}
+		}
+
+		if newFails > newSolves {
+			regressions = append(regressions, regression{cell.Maze, cell.Solver,
+				fmt.Sprintf("%d runs no longer solved, %d newly solved", newFails, newSolves)})
+		}
+		if mean, t, worse := pairedTTest(diffs); worse {
+			regressions = append(regressions, regression{cell.Maze, cell.Solver,
+				fmt.Sprintf("%.1f more steps on average (t=%.2f, n=%d)", mean, t, len(diffs))})
+		}
+	}
+	return regressions
+}
+
+func (rec *shootoutRecord) cell(maze, solver string) (cellRecord, bool) {
+	for _, c := range rec.Cells {
+		if c.Maze == maze && c.Solver == solver {
+			return c, true
+		}
+	}
+	return cellRecord{}, false
+}
+
+// One-sided critical values of Student's t at the 5% level, by degrees of freedom.
+var tCritical = []float64{
+	6.314, 2.920, 2.353, 2.132, 2.015, 1.943, 1.895, 1.860, 1.833, 1.812,
+	1.796, 1.782, 1.771, 1.761, 1.753, 1.746, 1.740, 1.734, 1.729, 1.725,
+	1.721, 1.717, 1.714, 1.711, 1.708, 1.706, 1.703, 1.701, 1.699, 1.697,
+}
+
+// Paired t-test on the per-run differences in steps (now - baseline).
+// worse is true if the differences are significantly greater than zero.
+// Needs at least two pairs; if every pair differs by the same amount the
+// change is systematic, and counts as significant whenever it is positive.
+func pairedTTest(diffs []float64) (mean, t float64, worse bool) {
+	n := len(diffs)
+	if n < 2 {
+		return 0, 0, false
+	}
+
+	for _, d := range diffs {
+		mean += d
+	}
+	mean /= float64(n)
+
+	variance := 0.0
+	for _, d := range diffs {
+		variance += (d - mean) * (d - mean)
+	}
+	variance /= float64(n - 1)
+
+	if variance == 0 {
+		if mean > 0 {
+			return mean, math.Inf(1), true
+		}
+		return mean, 0, false
+	}
+
+	t = mean / math.Sqrt(variance/float64(n))
+	critical := 1.645
+	if n-1 <= len(tCritical) {
+		critical = tCritical[n-2]
+	}
+	return mean, t, t > critical
+}
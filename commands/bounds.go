@@ -0,0 +1,186 @@
+// Solvers start out at (0,0) with no idea where in the maze they are.
+// Sometimes they know how big the maze is, and every room they explore, or see
+// an open wall into, must be inside it. bounds keeps track of that, so solvers
+// can tell which rooms could still be part of the maze and which can't.
+//
+// Where they don't know the size, the walls can still give it away. Every
+// room Icarus could reach is joined to the rest through open walls, so once
+// every room along one side of the area the maze could be in has a wall on
+// its outer side, there's nothing beyond it, and that side is the edge.
+package commands
+
+import "github.com/fwip/gc6/mazelib"
+
+type bounds struct {
+	width, height int                // of the maze, or 0 if we don't know
+	min, max      mazelib.Coordinate // corners of the rooms known to be in the maze
+	surveys       plot               // every room explored so far
+}
+
+// Bounds for a maze of the given size, where 0 means it could be any size
+func newBounds(width, height int) *bounds {
+	return &bounds{width: width, height: height, surveys: make(plot)}
+}
+
+// Record what a survey tells us: the room itself is in the maze,
+// and so is every room it has an open wall into.
+func (b *bounds) Record(c mazelib.Coordinate, s mazelib.Survey) {
+	b.surveys[c] = s
+	b.include(c)
+	for _, dir := range validDirections(s) {
+		b.include(nextCoord(c, dir))
+	}
+	b.findEdges()
+}
+
+// Look for sides of the maze that are walled off.
+// Finding one edge can settle where the maze is along one axis, which lets
+// us look for edges along the other, so keep going until nothing changes.
+func (b *bounds) findEdges() {
+	for {
+		min, max := b.Extents()
+		columns := b.width > 0 && b.max.X-b.min.X+1 == b.width
+		rows := b.height > 0 && b.max.Y-b.min.Y+1 == b.height
+		if columns && rows {
+			return
+		}
+
+		switch {
+		case rows && !columns:
+			// The rows are settled, so we can look down the outer columns
+			left := b.walled(mazelib.Coordinate{X: b.min.X, Y: min.Y}, mazelib.Coordinate{X: b.min.X, Y: max.Y}, mazelib.W)
+			right := b.walled(mazelib.Coordinate{X: b.max.X, Y: min.Y}, mazelib.Coordinate{X: b.max.X, Y: max.Y}, mazelib.E)
+			switch {
+			case b.width > 0 && left:
+				b.include(mazelib.Coordinate{X: b.min.X + b.width - 1, Y: b.min.Y})
+			case b.width > 0 && right:
+				b.include(mazelib.Coordinate{X: b.max.X - b.width + 1, Y: b.min.Y})
+			case left && right:
+				b.width = b.max.X - b.min.X + 1
+			default:
+				return
+			}
+		case columns && !rows:
+			top := b.walled(mazelib.Coordinate{X: min.X, Y: b.min.Y}, mazelib.Coordinate{X: max.X, Y: b.min.Y}, mazelib.N)
+			bottom := b.walled(mazelib.Coordinate{X: min.X, Y: b.max.Y}, mazelib.Coordinate{X: max.X, Y: b.max.Y}, mazelib.S)
+			switch {
+			case b.height > 0 && top:
+				b.include(mazelib.Coordinate{X: b.min.X, Y: b.min.Y + b.height - 1})
+			case b.height > 0 && bottom:
+				b.include(mazelib.Coordinate{X: b.min.X, Y: b.max.Y - b.height + 1})
+			case top && bottom:
+				b.height = b.max.Y - b.min.Y + 1
+			default:
+				return
+			}
+		default:
+			// Neither is settled, so the only way in or out we can rule out
+			// is through the rooms we know, and they must be walled in on
+			// every side at once
+			if b.width > 0 || b.height > 0 ||
+				!b.walled(b.min, mazelib.Coordinate{X: b.min.X, Y: b.max.Y}, mazelib.W) ||
+				!b.walled(mazelib.Coordinate{X: b.max.X, Y: b.min.Y}, b.max, mazelib.E) ||
+				!b.walled(b.min, mazelib.Coordinate{X: b.max.X, Y: b.min.Y}, mazelib.N) ||
+				!b.walled(mazelib.Coordinate{X: b.min.X, Y: b.max.Y}, b.max, mazelib.S) {
+				return
+			}
+			b.width, b.height = b.max.X-b.min.X+1, b.max.Y-b.min.Y+1
+		}
+	}
+}
+
+// Whether every room in the line from one to the other has been explored
+// and has a wall in the direction given
+func (b *bounds) walled(from, to mazelib.Coordinate, dir mazelib.Direction) bool {
+	for y := from.Y; y <= to.Y; y++ {
+		for x := from.X; x <= to.X; x++ {
+			s, explored := b.surveys[mazelib.Coordinate{X: x, Y: y}]
+			if !explored || canGo(s, dir) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (b *bounds) include(c mazelib.Coordinate) {
+	b.min, b.max = b.grow(c)
+}
+
+func (b *bounds) grow(c mazelib.Coordinate) (min, max mazelib.Coordinate) {
+	min, max = b.min, b.max
+	if c.X < min.X {
+		min.X = c.X
+	}
+	if c.Y < min.Y {
+		min.Y = c.Y
+	}
+	if c.X > max.X {
+		max.X = c.X
+	}
+	if c.Y > max.Y {
+		max.Y = c.Y
+	}
+	return min, max
+}
+
+// Could c be in the maze?
+// It can't if the maze would have to be wider or taller than it is to fit
+// both c and the rooms we already know about.
+func (b *bounds) Contains(c mazelib.Coordinate) bool {
+	min, max := b.grow(c)
+	if b.width > 0 && max.X-min.X >= b.width {
+		return false
+	}
+	if b.height > 0 && max.Y-min.Y >= b.height {
+		return false
+	}
+	return true
+}
+
+// How likely c is to be in the maze, if the maze is equally likely to be in
+// any position that fits around the rooms we know about.
+func (b *bounds) Likelihood(c mazelib.Coordinate) float64 {
+	return placements(c.X, b.min.X, b.max.X, b.width) * placements(c.Y, b.min.Y, b.max.Y, b.height)
+}
+
+// The fraction of the ways to place an edge of the given size over lo..hi
+// that also cover x.
+func placements(x, lo, hi, size int) float64 {
+	if size <= 0 {
+		return 1
+	}
+	first, last := hi-size+1, lo // where the low edge of the maze could be
+	if last < first {
+		return 0
+	}
+	from, to := first, last
+	if x-size+1 > from {
+		from = x - size + 1
+	}
+	if x < to {
+		to = x
+	}
+	if to < from {
+		return 0
+	}
+	return float64(to-from+1) / float64(last-first+1)
+}
+
+// Outside is the opposite of Contains, for passing to ShortestPathAvoiding.
+func (b *bounds) Outside(c mazelib.Coordinate) bool {
+	return !b.Contains(c)
+}
+
+// The corners of the area the maze must lie within.
+// Once we've seen rooms on opposite edges this is exactly the maze.
+func (b *bounds) Extents() (min, max mazelib.Coordinate) {
+	min, max = b.min, b.max
+	if b.width > 0 {
+		min.X, max.X = b.max.X-b.width+1, b.min.X+b.width-1
+	}
+	if b.height > 0 {
+		min.Y, max.Y = b.max.Y-b.height+1, b.min.Y+b.height-1
+	}
+	return min, max
+}
@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fwip/gc6/mazelib"
+)
+
+func TestBounds(t *testing.T) {
+	b := newBounds(3, 2)
+
+	// A room with an open wall to the left and below
+	b.Record(mazelib.Coordinate{}, mazelib.Survey{Top: true, Right: true})
+
+	min, max := b.Extents()
+	if want := (mazelib.Coordinate{X: -2, Y: 0}); min != want {
+		t.Errorf("expected the maze to start at %v, got %v", want, min)
+	}
+	if want := (mazelib.Coordinate{X: 1, Y: 1}); max != want {
+		t.Errorf("expected the maze to end at %v, got %v", want, max)
+	}
+	for _, c := range []mazelib.Coordinate{{X: -2, Y: 0}, {X: 1, Y: 0}, {X: -1, Y: 1}} {
+		if !b.Contains(c) {
+			t.Errorf("%v should be in the maze", c)
+		}
+	}
+	for _, c := range []mazelib.Coordinate{{X: -3, Y: 0}, {X: 2, Y: 0}, {X: 0, Y: -1}, {X: 0, Y: 2}} {
+		if b.Contains(c) {
+			t.Errorf("%v can't be in the maze", c)
+		}
+	}
+}
+
+func TestLikelihood(t *testing.T) {
+	b := newBounds(4, 1)
+	b.Record(mazelib.Coordinate{}, mazelib.Survey{Top: true, Bottom: true})
+
+	// The maze is either -2..1 or -1..2
+	expected := map[int]float64{-3: 0, -2: 0.5, -1: 1, 0: 1, 1: 1, 2: 0.5, 3: 0}
+	for x, want := range expected {
+		if got := b.Likelihood(mazelib.Coordinate{X: x}); got != want {
+			t.Errorf("expected likelihood %v at x=%d, got %v", want, x, got)
+		}
+	}
+}
+
+func TestUnknownMazeSize(t *testing.T) {
+	// A daedalus elsewhere could have a maze of any size
+	b := newBounds(0, 0)
+	b.Record(mazelib.Coordinate{}, mazelib.Survey{Top: true, Bottom: true, Left: true})
+	if !b.Contains(mazelib.Coordinate{X: 5}) {
+		t.Error("expected rooms past an open wall to be in the maze whatever its size")
+	}
+}
+
+func TestBoundsFindEdges(t *testing.T) {
+	// Knowing the height, a walled off column is the edge of the maze
+	b := newBounds(3, 1)
+	b.Record(mazelib.Coordinate{}, mazelib.Survey{Top: true, Bottom: true, Left: true})
+	if min, max := b.Extents(); min.X != 0 || max.X != 2 {
+		t.Errorf("expected the maze to be found at 0..2, got %v..%v", min, max)
+	}
+	if b.Contains(mazelib.Coordinate{X: -1}) {
+		t.Error("expected nothing past the left wall")
+	}
+
+	// Knowing nothing, the maze ends once the rooms we know are walled in
+	b = newBounds(0, 0)
+	b.Record(mazelib.Coordinate{}, mazelib.Survey{Top: true, Bottom: true, Left: true})
+	if b.width != 0 || b.height != 0 {
+		t.Errorf("expected the size to be unknown with a way out, got %dx%d", b.width, b.height)
+	}
+	b.Record(mazelib.Coordinate{X: 1}, mazelib.Survey{Top: true, Bottom: true, Right: true})
+	if b.width != 2 || b.height != 1 {
+		t.Errorf("expected the maze to be found to be 2x1, got %dx%d", b.width, b.height)
+	}
+	for _, c := range []mazelib.Coordinate{{X: 2}, {X: -1}, {Y: 1}, {Y: -1}} {
+		if b.Contains(c) {
+			t.Errorf("%v can't be in the maze", c)
+		}
+	}
+}
+
+func TestNearestGivesUpWithNowhereToGo(t *testing.T) {
+	obs := observation{Survey: mazelib.Survey{Top: true, Right: true, Bottom: true, Left: true}}
+	if _, err := newNearest(newBounds(0, 0)).Next(context.Background(), obs); err != errGaveUp {
+		t.Errorf("expected nearest to give up in a room with no way out, got %v", err)
+	}
+}
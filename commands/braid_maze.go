@@ -6,8 +6,8 @@ import (
 	"github.com/fwip/gc6/mazelib"
 )
 
-func braid() *Maze {
-	m := emptyMaze()
+func braid(r *rand.Rand) *Maze {
+	m := emptyMaze(r)
 	m.addBounds()
 
 	m.braidFill()
@@ -20,7 +20,7 @@ func (m *Maze) braidFill() {
 	for wallCount := 0; wallCount < limit; wallCount++ {
 		loc := m.randCoord()
 		dir := mazelib.E
-		if rand.Intn(2) == 1 {
+		if m.rng.Intn(2) == 1 {
 			dir = mazelib.S
 		}
 		loc2 := nextCoord(loc, dir)
@@ -31,7 +31,7 @@ func (m *Maze) braidFill() {
 			continue
 		} else {
 			r1.AddWall(dir)
-			r2.AddWall(int(direction(dir).Reverse()))
+			r2.AddWall(dir.Reverse())
 		}
 	}
 }
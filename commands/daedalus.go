@@ -16,18 +16,23 @@
 package commands
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/rand"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"time"
 
 	"github.com/fwip/gc6/mazelib"
 	"github.com/gin-gonic/gin"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"golang.org/x/net/websocket"
 )
 
 type Maze struct {
@@ -36,32 +41,43 @@ type Maze struct {
 	end        mazelib.Coordinate
 	icarus     mazelib.Coordinate
 	StepsTaken int
+	rng        *rand.Rand
+	lastMove   movement
+	wokenBy    string         // the request ID of the /awake that made it, if any
+	awoke      mazelib.Survey // what Icarus saw when he woke up
+
+	// The rules daedalus plays by, whatever Icarus thinks they are.
+	// Zero values mean no limit.
+	maxSteps int
+	deadline time.Time
+	over     error // why no more moves can be made, once they can't
 }
 
-type direction byte
-
-func (d direction) Reverse() direction {
-	switch d {
-	case mazelib.N:
-		return mazelib.S
-	case mazelib.S:
-		return mazelib.N
-	case mazelib.E:
-		return mazelib.W
-	case mazelib.W:
-		return mazelib.E
-	}
-	panic("Not a direction")
+// The last move made over HTTP, so that if Icarus retries it
+// he gets the same answer again rather than moving twice.
+type movement struct {
+	id     string
+	status int
+	reply  mazelib.Reply
 }
 
 // Tracking the current maze being solved
 
-// WARNING: This approach is not safe for concurrent use
-// This server is only intended to have a single client at a time
-// We would need a different and more complex approach if we wanted
-// concurrent connections than these simple package variables
+// The v1 APIs, over HTTP, the stream and gRPC, all share this one maze, so
+// they're only meant for a single client at a time. currentLock keeps them
+// from tripping over each other, but clients that want mazes of their own
+// should use /v2 sessions.
 var currentMaze *Maze
+var currentLock sync.Mutex
+
+// Returned for moves made before Icarus has woken up in a maze
+var errNoMaze = errors.New("no maze yet, call /awake first")
+
 var scores []int
+var forfeits int // mazes that were over before Icarus found the treasure
+
+// Sessions in the /v2 API each have their own maze, but share scores
+var scoresLock sync.Mutex
 
 // Defining the daedalus command.
 // This will be called as 'laybrinth daedalus'
@@ -72,7 +88,10 @@ var daedalusCmd = &cobra.Command{
 	Long: `Daedalus's job is to create a challenging Labyrinth for his opponent
   Icarus to solve.
 
-  Daedalus runs a server which Icarus clients can connect to to solve laybrinths.`,
+  Daedalus runs a server which Icarus clients can connect to to solve laybrinths.
+  He gives Icarus --max-steps moves and --time-limit to solve each one, and
+  refuses any moves after that, or after the treasure is found. Walking into
+  a wall counts as a move. Mazes Icarus doesn't solve are counted as forfeits.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		RunServer()
 	},
@@ -88,6 +107,48 @@ func init() {
 
 // Runs the web server
 func RunServer() {
+	ln, err := listen()
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// Once Icarus is done, wait for both servers to finish answering
+	var w sync.WaitGroup
+	if viper.GetInt("grpc-port") > 0 {
+		gln, err := listenGRPC()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		w.Add(1)
+		go func() {
+			serveGRPC(gln)
+			w.Done()
+		}()
+	}
+
+	serve(ln)
+	w.Wait()
+}
+
+// Closed by stopServers once Icarus is done, to shut the servers down
+var stopping = make(chan struct{})
+var stopOnce sync.Once
+
+// Shut the servers down gracefully, letting them finish the requests
+// they're answering, including the one that asked them to stop
+func stopServers() {
+	stopOnce.Do(func() { close(stopping) })
+}
+
+// Start listening on port. Requests are accepted from the moment this
+// returns, even if serve hasn't got going yet.
+func listen() (net.Listener, error) {
+	return net.Listen("tcp", ":"+viper.GetString("port"))
+}
+
+func serve(ln net.Listener) {
 	// Adding handling so that even when ctrl+c is pressed we still print
 	// out the results prior to exiting.
 	c := make(chan os.Signal, 1)
@@ -98,16 +159,35 @@ func RunServer() {
 		os.Exit(1)
 	}()
 
-	// Using gin-gonic/gin to handle our routing
+	srv := &http.Server{Handler: newRouter()}
+	stopped := make(chan struct{})
+	go func() {
+		<-stopping
+		srv.Shutdown(context.Background())
+		close(stopped)
+	}()
+
+	if err := srv.Serve(ln); err != http.ErrServerClosed {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	<-stopped
+}
+
+// Using gin-gonic/gin to handle our routing
+func newRouter() *gin.Engine {
 	r := gin.Default()
 	v1 := r.Group("/")
 	{
 		v1.GET("/awake", GetStartingPoint)
 		v1.GET("/move/:direction", MoveDirection)
+		v1.POST("/moves", MoveBatch)
 		v1.GET("/done", End)
+		v1.GET("/healthz", Healthz)
+		v1.GET("/stream", gin.WrapH(websocket.Server{Handler: StreamMoves}))
 	}
-
-	r.Run(":" + viper.GetString("port"))
+	addV2Routes(r)
+	return r
 }
 
 // Ends a session and prints the results.
@@ -115,72 +195,269 @@ func RunServer() {
 //   the number of times he wants to solve the laybrinth.
 func End(c *gin.Context) {
 	printResults()
-	os.Exit(1)
+	c.JSON(http.StatusOK, mazelib.Reply{})
+	stopServers()
+}
+
+// Lets clients check daedalus is up before they start
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
 // initializes a new maze and places Icarus in his awakening location
+// A retried /awake, with the same request ID, gets the same maze again.
 func GetStartingPoint(c *gin.Context) {
-	initializeMaze()
-	startRoom, err := currentMaze.Discover(currentMaze.Icarus())
+	startRoom, err := wakeIcarus(c.GetHeader(mazelib.RequestIDHeader))
 	if err != nil {
 		fmt.Println("Icarus is outside of the maze. This shouldn't ever happen")
 		fmt.Println(err)
 		os.Exit(-1)
 	}
-	mazelib.PrintMaze(currentMaze)
 
 	c.JSON(http.StatusOK, mazelib.Reply{Survey: startRoom})
 }
 
 // The API response to the /move/:direction address
+// Moves carrying a request ID are only made once, however many times
+// they're sent.
 func MoveDirection(c *gin.Context) {
-	var err error
+	m, unlock := lockCurrentMaze()
+	defer unlock()
+	if m == nil {
+		c.JSON(http.StatusBadRequest, mazelib.Reply{Error: true, Message: errNoMaze.Error()})
+		return
+	}
+	c.JSON(moveOnce(m, c.GetHeader(mazelib.RequestIDHeader), c.Param("direction")))
+}
 
-	switch c.Param("direction") {
-	case "left":
-		err = currentMaze.MoveLeft()
-	case "right":
-		err = currentMaze.MoveRight()
-	case "down":
-		err = currentMaze.MoveDown()
-	case "up":
-		err = currentMaze.MoveUp()
+// Make a move unless it has the same request ID as the last one,
+// in which case answer the same way again.
+func moveOnce(m *Maze, id, param string) (int, mazelib.Reply) {
+	if last := m.lastMove; id != "" && id == last.id {
+		return last.status, last.reply
 	}
 
-	var r mazelib.Reply
+	status, r := moveReply(m, param)
+	if id != "" {
+		m.lastMove = movement{id: id, status: status, reply: r}
+	}
+	return status, r
+}
 
-	if err != nil {
-		r.Error = true
-		r.Message = err.Error()
-		c.JSON(409, r)
+// The API response to a POST to /moves, which makes a list of moves in
+// turn. They stop at the first wall or the treasure, and the reply has a
+// survey for each one made.
+func MoveBatch(c *gin.Context) {
+	var req mazelib.MovesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, mazelib.MovesReply{Error: true, Message: err.Error()})
 		return
 	}
 
-	s, e := currentMaze.LookAround()
+	m, unlock := lockCurrentMaze()
+	defer unlock()
+	if m == nil {
+		c.JSON(http.StatusBadRequest, mazelib.MovesReply{Error: true, Message: errNoMaze.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, moveAll(m, req.Directions))
+}
 
-	if e != nil {
-		if e == mazelib.ErrVictory {
-			scores = append(scores, currentMaze.StepsTaken)
+func moveAll(m *Maze, dirs []mazelib.Direction) mazelib.MovesReply {
+	r := mazelib.MovesReply{Surveys: []mazelib.Survey{}}
+	for _, dir := range dirs {
+		s, err := moveIcarus(m, dir)
+		switch err {
+		case nil:
+			r.Surveys = append(r.Surveys, s)
+		case mazelib.ErrVictory:
+			r.Surveys = append(r.Surveys, s)
 			r.Victory = true
-			r.Message = fmt.Sprintf("Victory achieved in %d steps \n", currentMaze.StepsTaken)
-		} else {
+			r.Message = fmt.Sprintf("Victory achieved in %d steps \n", m.StepsTaken)
+			return r
+		default:
+			r.Wall = err == mazelib.ErrWall
 			r.Error = true
 			r.Message = err.Error()
+			return r
 		}
 	}
+	return r
+}
+
+// The API for the /stream websocket. Each mazelib.StreamMove received is
+// made just as /move/:direction would make it, and answered with a
+// mazelib.StreamReply.
+func StreamMoves(ws *websocket.Conn) {
+	defer ws.Close()
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(ws, &msg); err != nil {
+			return
+		}
+
+		// A 409 would look like a wall, so anything wrong with the move is a 400
+		var move mazelib.StreamMove
+		status, r := http.StatusBadRequest, mazelib.Reply{Error: true}
+		m, unlock := lockCurrentMaze()
+		if err := json.Unmarshal(msg, &move); err != nil {
+			r.Message = err.Error()
+		} else if m == nil {
+			r.Message = errNoMaze.Error()
+		} else {
+			status, r = moveReply(m, move.Direction.String())
+		}
+		unlock()
+		if err := websocket.JSON.Send(ws, mazelib.StreamReply{Reply: r, Status: status}); err != nil {
+			return
+		}
+	}
+}
+
+func moveReply(m *Maze, param string) (int, mazelib.Reply) {
+	var r mazelib.Reply
+
+	dir, err := mazelib.ParseDirection(param)
+	if err != nil {
+		r.Error = true
+		r.Message = err.Error()
+		return http.StatusBadRequest, r
+	}
+
+	s, err := moveIcarus(m, dir)
+	switch err {
+	case nil:
+	case mazelib.ErrVictory:
+		r.Victory = true
+		r.Message = fmt.Sprintf("Victory achieved in %d steps \n", m.StepsTaken)
+	default:
+		r.Error = true
+		r.Message = err.Error()
+		return moveStatus(err), r
+	}
 
 	r.Survey = s
 
-	c.JSON(http.StatusOK, r)
+	return http.StatusOK, r
+}
+
+// The status for a move that couldn't be made
+func moveStatus(err error) int {
+	switch err {
+	case mazelib.ErrSolved, mazelib.ErrOutOfSteps, mazelib.ErrOutOfTime, mazelib.ErrForfeited:
+		return http.StatusGone
+	}
+	return http.StatusConflict
+}
+
+// The current maze, locked until unlock is called.
+// It's nil until Icarus has woken up.
+func lockCurrentMaze() (m *Maze, unlock func()) {
+	currentLock.Lock()
+	return currentMaze, currentLock.Unlock
+}
+
+// Starts a new maze, returning what Icarus sees when he wakes up in it.
+// If he hadn't finished the last one, that's a forfeit. If id is the
+// request ID the current maze was made for, this is a retry, and he gets
+// that maze again rather than forfeiting it.
+func wakeIcarus(id string) (mazelib.Survey, error) {
+	currentLock.Lock()
+	defer currentLock.Unlock()
+	if currentMaze != nil {
+		if id != "" && id == currentMaze.wokenBy {
+			return currentMaze.awoke, nil
+		}
+		currentMaze.finish(mazelib.ErrForfeited)
+	}
+	m, s, err := wake()
+	m.wokenBy = id
+	currentMaze = m
+	return s, err
+}
+
+// Makes a new maze and shows it, along with what Icarus sees when he wakes up in it.
+// He has max-steps and time-limit to solve it.
+func wake() (*Maze, mazelib.Survey, error) {
+	m := createMaze()
+	m.maxSteps = viper.GetInt("max-steps")
+	if limit := viper.GetDuration("time-limit"); limit > 0 {
+		m.deadline = time.Now().Add(limit)
+	}
+	s, err := m.Discover(m.Icarus())
+	if err != nil {
+		return m, s, err
+	}
+	m.awoke = s
+	mazelib.PrintMaze(m)
+	return m, s, nil
+}
+
+// Moves Icarus through a maze, keeping score once he finds the
+// treasure. Returns mazelib.ErrVictory along with the survey when he does.
+func moveIcarus(m *Maze, dir mazelib.Direction) (mazelib.Survey, error) {
+	if err := m.playable(); err != nil {
+		return mazelib.Survey{}, err
+	}
+	if err := m.moveDir(dir); err != nil {
+		// Walking into a wall uses up a step too, as icarus counts it
+		m.StepsTaken++
+		return mazelib.Survey{}, err
+	}
+
+	s, err := m.LookAround()
+	if err == mazelib.ErrVictory {
+		m.finish(mazelib.ErrSolved)
+	}
+	return s, err
+}
+
+// Returns why Icarus can't move any more, if he can't: he's found the
+// treasure, run out of steps or time, or given up.
+func (m *Maze) playable() error {
+	switch {
+	case m.over != nil:
+	case m.maxSteps > 0 && m.StepsTaken >= m.maxSteps:
+		m.finish(mazelib.ErrOutOfSteps)
+	case !m.deadline.IsZero() && time.Now().After(m.deadline):
+		m.finish(mazelib.ErrOutOfTime)
+	}
+	return m.over
 }
 
-func initializeMaze() {
-	currentMaze = createMaze()
+// Ends the maze for the given reason, scoring it if Icarus found the
+// treasure, and counting it as a forfeit if not. A maze only ends once.
+func (m *Maze) finish(reason error) {
+	if m.over != nil {
+		return
+	}
+	m.over = reason
+
+	scoresLock.Lock()
+	defer scoresLock.Unlock()
+	if reason == mazelib.ErrSolved {
+		scores = append(scores, m.StepsTaken)
+	} else {
+		forfeits++
+	}
 }
 
-// Print to the terminal the average steps to solution for the current session
+// Print to the terminal the average steps to solution for the current session.
+// Any mazes still being solved are forfeits.
 func printResults() {
+	m, unlock := lockCurrentMaze()
+	if m != nil {
+		m.finish(mazelib.ErrForfeited)
+	}
+	unlock()
+	abandonSessions()
+
+	scoresLock.Lock()
+	defer scoresLock.Unlock()
 	fmt.Printf("Labyrinth solved %d times with an avg of %d steps\n", len(scores), mazelib.AvgScores(scores))
+	if forfeits > 0 {
+		fmt.Printf("Labyrinth forfeited %d times\n", forfeits)
+	}
 }
 
 // Return a room from the maze
@@ -262,7 +539,7 @@ func (m *Maze) MoveLeft() error {
 		return e
 	}
 	if s.Left {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -283,7 +560,7 @@ func (m *Maze) MoveRight() error {
 		return e
 	}
 	if s.Right {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -304,7 +581,7 @@ func (m *Maze) MoveUp() error {
 		return e
 	}
 	if s.Top {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -325,7 +602,7 @@ func (m *Maze) MoveDown() error {
 		return e
 	}
 	if s.Bottom {
-		return errors.New("Can't walk through walls")
+		return mazelib.ErrWall
 	}
 
 	x, y := m.Icarus()
@@ -340,8 +617,8 @@ func (m *Maze) MoveDown() error {
 
 // Creates a maze without any walls
 // Good starting point for additive algorithms
-func emptyMaze() *Maze {
-	z := Maze{}
+func emptyMaze(r *rand.Rand) *Maze {
+	z := Maze{rng: r}
 	ySize := viper.GetInt("height")
 	xSize := viper.GetInt("width")
 
@@ -358,8 +635,8 @@ func emptyMaze() *Maze {
 
 // Creates a maze with all walls
 // Good starting point for subtractive algorithms
-func fullMaze() *Maze {
-	z := emptyMaze()
+func fullMaze(r *rand.Rand) *Maze {
+	z := emptyMaze(r)
 	ySize := viper.GetInt("height")
 	xSize := viper.GetInt("width")
 
@@ -372,11 +649,13 @@ func fullMaze() *Maze {
 	return z
 }
 
-func getSolvable(generate func() *Maze) *Maze {
-	m := generate()
+// Generates mazes until one is solvable.
+// The same generator and seed will always produce the same maze.
+func getSolvable(generate mazeGen, r *rand.Rand) *Maze {
+	m := generate(r)
 
 	for !m.isSolvable() {
-		m = generate()
+		m = generate(r)
 		m.placeRandomly()
 	}
 	m.SetStartPoint(m.start.X, m.start.Y)
@@ -391,5 +670,5 @@ func getSolvable(generate func() *Maze) *Maze {
 }
 
 func createMaze() *Maze {
-	return getSolvable(growingTree)
+	return getSolvable(growingTree, rand.New(rand.NewSource(rand.Int63())))
 }
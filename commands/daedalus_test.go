@@ -0,0 +1,373 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+)
+
+func TestRetriedMovesAreMadeOnce(t *testing.T) {
+	setSize(3, 1)
+	currentMaze = empty(rand.New(rand.NewSource(1)))
+	currentMaze.SetStartPoint(0, 0)
+	currentMaze.SetTreasure(2, 0)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	move := func(id string) int {
+		req, _ := http.NewRequest("GET", server.URL+"/move/right", nil)
+		req.Header.Set(mazelib.RequestIDHeader, id)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	move("a")
+	move("a")
+	if x, _ := currentMaze.Icarus(); x != 1 || currentMaze.StepsTaken != 1 {
+		t.Errorf("expected a retried move to be made once, Icarus is at x=%d after %d steps", x, currentMaze.StepsTaken)
+	}
+
+	if status := move("b"); status != http.StatusOK {
+		t.Errorf("expected a new move to be made, got %d", status)
+	}
+	if x, _ := currentMaze.Icarus(); x != 2 {
+		t.Errorf("expected Icarus to reach the treasure, he's at x=%d", x)
+	}
+}
+
+func TestHealthz(t *testing.T) {
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	if err := mazelib.NewClient(server.URL).Ready(); err != nil {
+		t.Errorf("expected daedalus to be ready, got %v", err)
+	}
+}
+
+func TestMoveBatch(t *testing.T) {
+	setSize(3, 1)
+	currentMaze = empty(rand.New(rand.NewSource(1)))
+	currentMaze.SetStartPoint(0, 0)
+	currentMaze.SetTreasure(2, 0)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+	c := mazelib.NewClient(server.URL)
+
+	surveys, err := c.Moves([]mazelib.Direction{mazelib.E, mazelib.N, mazelib.E})
+	if err != mazelib.ErrWall || len(surveys) != 1 {
+		t.Errorf("expected to stop at the wall after 1 move, got %d surveys and %v", len(surveys), err)
+	}
+
+	surveys, err = c.Moves([]mazelib.Direction{mazelib.E, mazelib.W})
+	if err != mazelib.ErrVictory || len(surveys) != 1 {
+		t.Errorf("expected to stop at the treasure after 1 move, got %d surveys and %v", len(surveys), err)
+	}
+	if currentMaze.StepsTaken != 3 {
+		t.Errorf("expected 3 steps to be taken, counting the wall, got %d", currentMaze.StepsTaken)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	setSize(3, 1)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	// The status and error of a raw request, to check what old clients would see
+	post := func(path, body string) (int, string) {
+		resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var rep mazelib.ErrorReply
+		json.NewDecoder(resp.Body).Decode(&rep)
+		return resp.StatusCode, rep.Error
+	}
+
+	if status, _ := post("/v2/sessions", ""); status != http.StatusCreated {
+		t.Errorf("expected a new session to be 201 Created, got %d", status)
+	}
+
+	session, _, err := mazelib.NewClient(server.URL).Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := empty(rand.New(rand.NewSource(1)))
+	m.SetStartPoint(0, 0)
+	m.SetTreasure(2, 0)
+	sessions.byID[session.ID].maze = m
+	path := "/v2/sessions/" + session.ID + "/move"
+
+	if status, msg := post(path, `{"direction": "up"}`); status != http.StatusConflict || msg == "" {
+		t.Errorf("expected a move into a wall to be 409 Conflict with an error, got %d %q", status, msg)
+	}
+	if status, msg := post(path, `{"direction": "sideways"}`); status != http.StatusBadRequest || msg == "" {
+		t.Errorf("expected a bad direction to be 400 Bad Request with an error, got %d %q", status, msg)
+	}
+	if status, _ := post("/v2/sessions/nope/move", `{"direction": "right"}`); status != http.StatusNotFound {
+		t.Errorf("expected an unknown session to be 404 Not Found, got %d", status)
+	}
+
+	if _, err := session.Moves([]mazelib.Direction{mazelib.E, mazelib.E}); err != mazelib.ErrVictory {
+		t.Errorf("expected to find the treasure, got %v", err)
+	}
+	if _, err := session.Move(mazelib.W); !errors.Is(err, mazelib.ErrSessionFinished) {
+		t.Errorf("expected ErrSessionFinished after the treasure was found, got %v", err)
+	}
+	if status, _ := post(path, `{"direction": "left"}`); status != http.StatusGone {
+		t.Errorf("expected a move in a finished session to be 410 Gone, got %d", status)
+	}
+	if err := session.End(); err != nil {
+		t.Errorf("expected ending a finished session to be fine, got %v", err)
+	}
+	if status, _ := post(path, `{"direction": "left"}`); status != http.StatusNotFound {
+		t.Errorf("expected an ended session to be deleted, got %d", status)
+	}
+}
+
+func TestIdleSessionsAreReaped(t *testing.T) {
+	setSize(3, 1)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+	c := mazelib.NewClient(server.URL)
+
+	idle, _, err := c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy, _, err := c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.byID[busy.ID].lastUsed = time.Now().Add(sessionIdle)
+
+	reapSessions(time.Now().Add(sessionIdle + time.Second))
+	if _, ok := sessions.byID[idle.ID]; ok {
+		t.Error("expected an idle session to be deleted")
+	}
+	if _, ok := sessions.byID[busy.ID]; !ok {
+		t.Error("expected a session in use to be kept")
+	}
+	if _, err := idle.Move(mazelib.E); err != mazelib.ErrSessionNotFound {
+		t.Errorf("expected ErrSessionNotFound for a deleted session, got %v", err)
+	}
+}
+
+// Give daedalus a clean slate for the test, putting everything back after.
+// The maze size is set to width x height for the test too.
+func isolateDaedalus(t *testing.T, width, height int) {
+	oldMaze, oldScores, oldForfeits := currentMaze, scores, forfeits
+	oldSessions, oldRequests := sessions.byID, sessions.byRequest
+	oldWidth, oldHeight := viper.Get("width"), viper.Get("height")
+	oldSteps, oldLimit := viper.Get("max-steps"), viper.Get("time-limit")
+	t.Cleanup(func() {
+		currentMaze, scores, forfeits = oldMaze, oldScores, oldForfeits
+		sessions.byID, sessions.byRequest = oldSessions, oldRequests
+		viper.Set("width", oldWidth)
+		viper.Set("height", oldHeight)
+		viper.Set("max-steps", oldSteps)
+		viper.Set("time-limit", oldLimit)
+	})
+
+	currentMaze, scores, forfeits = nil, nil, 0
+	sessions.byID, sessions.byRequest = make(map[string]*session), make(map[string]*session)
+	setSize(width, height)
+}
+
+// A maze with Icarus at the left and the treasure two rooms to his right
+func corridor() *Maze {
+	m := empty(rand.New(rand.NewSource(1)))
+	m.SetStartPoint(0, 0)
+	m.SetTreasure(2, 0)
+	return m
+}
+
+func TestServerRules(t *testing.T) {
+	isolateDaedalus(t, 3, 1)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+	c := mazelib.NewClient(server.URL)
+
+	// Moves after the treasure is found are refused, and it's only scored once
+	currentMaze = corridor()
+	c.Move(mazelib.E)
+	if _, err := c.Move(mazelib.E); err != mazelib.ErrVictory {
+		t.Fatalf("expected to find the treasure, got %v", err)
+	}
+	c.Move(mazelib.W)
+	if _, err := c.Move(mazelib.E); err != mazelib.ErrSolved {
+		t.Errorf("expected moves after the treasure is found to be refused, got %v", err)
+	}
+	if len(scores) != 1 {
+		t.Errorf("expected the maze to be scored once, got %d scores", len(scores))
+	}
+
+	currentMaze = corridor()
+	currentMaze.maxSteps = 1
+	c.Move(mazelib.E)
+	if _, err := c.Move(mazelib.E); err != mazelib.ErrOutOfSteps {
+		t.Errorf("expected to run out of steps, got %v", err)
+	}
+
+	// Walking into a wall uses up a step too
+	currentMaze = corridor()
+	currentMaze.maxSteps = 1
+	c.Move(mazelib.N)
+	if _, err := c.Move(mazelib.E); err != mazelib.ErrOutOfSteps {
+		t.Errorf("expected walking into a wall to use up a step, got %v", err)
+	}
+
+	currentMaze = corridor()
+	currentMaze.deadline = time.Now().Add(-time.Second)
+	if _, err := c.Move(mazelib.E); err != mazelib.ErrOutOfTime {
+		t.Errorf("expected to run out of time, got %v", err)
+	}
+
+	// Waking up again before finding the treasure, or ending a session, is a forfeit
+	currentMaze = corridor()
+	if _, err := c.Awake(); err != nil {
+		t.Fatal(err)
+	}
+	session, _, err := c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := session.End(); err != nil {
+		t.Fatal(err)
+	}
+	if forfeits != 5 {
+		t.Errorf("expected 5 forfeits, got %d", forfeits)
+	}
+}
+
+// Lets each request through, except that the first to path is made but
+// never answered, as if the reply were lost
+func loseFirstReply(next http.Handler, path string) http.Handler {
+	lost := false
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == path && !lost {
+			lost = true
+			next.ServeHTTP(httptest.NewRecorder(), r)
+			panic(http.ErrAbortHandler)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestRetriedWakeUpsAreNotForfeits(t *testing.T) {
+	for _, path := range []string{"/awake", "/v2/sessions"} {
+		isolateDaedalus(t, 3, 1)
+		server := httptest.NewServer(loseFirstReply(newRouter(), path))
+		c := mazelib.NewClient(server.URL)
+		c.Retries, c.Backoff = 1, time.Millisecond
+
+		var err error
+		if path == "/awake" {
+			_, err = c.Awake()
+		} else {
+			_, _, err = c.Start()
+		}
+		if err != nil {
+			t.Errorf("%s: expected the retry to work, got %v", path, err)
+		}
+		// Without dedupe, there would be a forfeit or a session left to become one
+		if forfeits != 0 || len(sessions.byID) > 1 {
+			t.Errorf("%s: expected a retry to get the same maze, got %d forfeits and %d sessions", path, forfeits, len(sessions.byID))
+		}
+		server.Close()
+	}
+}
+
+func TestSessionsRunOut(t *testing.T) {
+	isolateDaedalus(t, 3, 1)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+	c := mazelib.NewClient(server.URL)
+
+	viper.Set("max-steps", 1)
+	steps, _, err := c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	viper.Set("max-steps", 0)
+	viper.Set("time-limit", time.Nanosecond)
+	clock, _, err := c.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessions.byID[steps.ID].maze = corridor()
+	sessions.byID[steps.ID].maze.maxSteps = 1
+
+	steps.Move(mazelib.E)
+	if _, err := steps.Move(mazelib.E); !errors.Is(err, mazelib.ErrSessionFinished) || !errors.Is(err, mazelib.ErrOutOfSteps) {
+		t.Errorf("expected the session to be out of steps, got %v", err)
+	}
+	if _, err := clock.Moves([]mazelib.Direction{mazelib.E}); !errors.Is(err, mazelib.ErrSessionFinished) || !errors.Is(err, mazelib.ErrOutOfTime) {
+		t.Errorf("expected the session to be out of time, got %v", err)
+	}
+	if forfeits != 2 {
+		t.Errorf("expected sessions that run out to be forfeits, got %d", forfeits)
+	}
+
+	// Once they've been over for a while, they're deleted
+	reapSessions(time.Now().Add(sessionLinger + time.Second))
+	if len(sessions.byID) != 0 {
+		t.Errorf("expected sessions that are over to be deleted, %d are left", len(sessions.byID))
+	}
+}
+
+func TestDoneStopsServer(t *testing.T) {
+	t.Cleanup(func() {
+		stopping = make(chan struct{})
+		stopOnce = sync.Once{}
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := make(chan struct{})
+	go func() {
+		serve(ln)
+		close(served)
+	}()
+
+	if err := mazelib.NewClient("http://" + ln.Addr().String()).Done(); err != nil {
+		t.Errorf("expected /done to be answered, got %v", err)
+	}
+	select {
+	case <-served:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the server to stop after /done")
+	}
+}
+
+func TestMovesBeforeAwake(t *testing.T) {
+	currentMaze = nil
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+	c := mazelib.NewClient(server.URL)
+
+	_, err := c.Move(mazelib.E)
+	var statusErr *mazelib.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest || statusErr.Message == "" {
+		t.Errorf("expected a move before /awake to be 400 Bad Request with a message, got %v", err)
+	}
+	_, err = c.Moves([]mazelib.Direction{mazelib.E})
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest || statusErr.Message == "" {
+		t.Errorf("expected moves before /awake to be 400 Bad Request with a message, got %v", err)
+	}
+}
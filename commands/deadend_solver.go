@@ -0,0 +1,92 @@
+// Works like nearest, but fills in dead ends as it finds them.
+// Once a corridor is known to lead nowhere new, it's left out of every later
+// search, so the solver never walks back into it.
+package commands
+
+import "github.com/fwip/gc6/mazelib"
+
+type deadEndFiller struct {
+	memory plot
+	filled map[mazelib.Coordinate]bool
+	pos    mazelib.Coordinate
+	path   path
+}
+
+func newDeadEndFiller() solver {
+	return &deadEndFiller{}
+}
+
+// Fill c if it's a dead end, then keep filling back along the corridor.
+// A room is a dead end if none of its exits lead somewhere unexplored, and
+// at most one leads to a room that isn't already filled.
+// We never fill the room we're standing in, since we still have to leave it.
+func (s *deadEndFiller) fill(c mazelib.Coordinate) {
+	for c != s.pos && !s.filled[c] {
+		svy, explored := s.memory[c]
+		if !explored {
+			return
+		}
+
+		var open []mazelib.Coordinate
+		for _, dir := range validDirections(svy) {
+			n := nextCoord(c, dir)
+			if s.filled[n] {
+				continue
+			}
+			if _, explored := s.memory[n]; !explored {
+				return
+			}
+			open = append(open, n)
+		}
+		if len(open) > 1 {
+			return
+		}
+
+		s.filled[c] = true
+		if len(open) == 0 {
+			return
+		}
+		c = open[0]
+	}
+}
+
+func (s *deadEndFiller) isFilled(c mazelib.Coordinate) bool {
+	return s.filled[c]
+}
+
+// Returns false once everywhere we can reach is explored or filled
+func (s *deadEndFiller) nextDir() (mazelib.Direction, bool) {
+	if len(s.path) == 0 {
+		s.path = s.memory.ShortestPathAvoiding(s.pos, s.isFilled)
+	}
+	if len(s.path) == 0 {
+		return 0, false
+	}
+	next := s.path[0]
+	s.path = s.path[1:]
+	return next, true
+}
+
+func (s *deadEndFiller) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
+	defer close(cmds)
+
+	s.memory = make(plot)
+	s.filled = make(map[mazelib.Coordinate]bool)
+
+	for survey := range surveys {
+		s.memory.Record(s.pos, survey)
+
+		// Exploring this room may have made its neighbors into dead ends
+		for _, dir := range validDirections(survey) {
+			s.fill(nextCoord(s.pos, dir))
+		}
+
+		newdir, ok := s.nextDir()
+		if !ok {
+			return
+		}
+
+		s.pos = nextCoord(s.pos, newdir)
+		cmds <- newdir
+	}
+}
@@ -1,7 +1,9 @@
 package commands
 
-func empty() *Maze {
-	m := emptyMaze()
+import "math/rand"
+
+func empty(r *rand.Rand) *Maze {
+	m := emptyMaze(r)
 	m.addBounds()
 
 	return m
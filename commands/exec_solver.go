@@ -0,0 +1,215 @@
+// execSolver lets a program written in any language compete, by running it
+// as a child process and talking to it over its stdin and stdout.
+//
+// The protocol is one JSON object per line. Before each move the solver is
+// sent what Icarus can see:
+//
+//	{"survey":{"top":true,"right":false,"bottom":false,"left":true},
+//	 "position":{"x":0,"y":0},"steps":0,"steps_left":500}
+//
+// plus "error" with a message if the last move failed. It answers with the
+// direction to move in, as used by the /move endpoint:
+//
+//	{"direction":"right"}
+//
+// When the run is over it is sent one last observation with "done" set, and
+// "victory" too if it found the treasure, and then its stdin is closed. A
+// solver that exits or closes its stdout early has given up.
+//
+// Each run starts a fresh process. It has time-limit to get going and
+// answer the first observation, and move-timeout for each one after that.
+// Anything it writes to stderr is passed through, so it can be used for
+// debugging.
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+)
+
+// How long a solver gets to exit by itself once its stdin is closed
+const execGrace = time.Second
+
+type execObservation struct {
+	Survey    mazelib.Survey     `json:"survey"`
+	Position  mazelib.Coordinate `json:"position"`
+	Steps     int                `json:"steps"`
+	StepsLeft int                `json:"steps_left"`
+	Error     string             `json:"error,omitempty"`
+	Done      bool               `json:"done,omitempty"`
+	Victory   bool               `json:"victory,omitempty"`
+}
+
+type execReply struct {
+	Direction mazelib.Direction `json:"direction"`
+}
+
+type execSolver struct {
+	args  []string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	lines chan []byte // read from stdout, closed once it is
+}
+
+// Makes a solverGen for the command line args
+func newExecSolver(args []string) solverGen {
+	return func(*bounds) explorer { return &execSolver{args: args} }
+}
+
+// The solvers given with --exec, named after the programs they run and
+// their arguments
+func execSolvers() []namedSolver {
+	var named []namedSolver
+	for _, line := range viper.GetStringSlice("exec") {
+		args := strings.Fields(line)
+		if len(args) == 0 {
+			continue
+		}
+		name := strings.Join(append([]string{filepath.Base(args[0])}, args[1:]...), " ")
+		named = append(named, namedSolver{name, newExecSolver(args)})
+	}
+	return named
+}
+
+// Start the process. explore does this before any clocks start.
+func (s *execSolver) Start() error {
+	cmd := exec.Command(s.args[0], s.args[1:]...)
+	cmd.Stderr = os.Stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	s.cmd, s.stdin = cmd, stdin
+
+	s.lines = make(chan []byte)
+	go func() {
+		defer close(s.lines)
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			s.lines <- append([]byte(nil), scanner.Bytes()...)
+		}
+	}()
+	return nil
+}
+
+// Write obs to the solver's stdin, giving up once ctx is done.
+// A solver that has stopped reading leaves the write stuck when the pipe
+// fills, so it's killed then, which breaks the pipe and ends the write.
+func (s *execSolver) send(ctx context.Context, obs observation, done bool) error {
+	msg := execObservation{
+		Survey:    obs.Survey,
+		Position:  obs.Position,
+		Steps:     obs.Steps,
+		StepsLeft: obs.StepsLeft,
+		Done:      done,
+		Victory:   obs.Victory,
+	}
+	if obs.Err != nil {
+		msg.Error = obs.Err.Error()
+	}
+	line, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := s.stdin.Write(append(line, '\n'))
+		written <- err
+	}()
+	select {
+	case err := <-written:
+		return err
+	case <-ctx.Done():
+		s.cmd.Process.Kill()
+		return ctx.Err()
+	}
+}
+
+func (s *execSolver) Next(ctx context.Context, obs observation) (mazelib.Direction, error) {
+	if s.cmd == nil {
+		if err := s.Start(); err != nil {
+			return 0, err
+		}
+	}
+
+	// If it has already quit, the write fails; treat that as giving up too
+	if err := s.send(ctx, obs, false); err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 0, errGaveUp
+	}
+
+	select {
+	case line, ok := <-s.lines:
+		if !ok {
+			return 0, errGaveUp
+		}
+		var reply execReply
+		if err := json.Unmarshal(line, &reply); err != nil {
+			return 0, fmt.Errorf("%s: bad reply %q: %v", s.args[0], line, err)
+		}
+		if !reply.Direction.Valid() {
+			return 0, fmt.Errorf("%s: bad reply %q: %v", s.args[0], line, mazelib.ErrInvalidDirection)
+		}
+		return reply.Direction, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+func (s *execSolver) Done(obs observation) {
+	if s.cmd == nil {
+		return
+	}
+
+	// A solver that's out of time isn't listening, so don't wait on it
+	grace := execGrace
+	if errors.Is(obs.Err, context.DeadlineExceeded) {
+		grace = 0
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), execGrace)
+		s.send(ctx, obs, true)
+		cancel()
+	}
+	s.stdin.Close()
+
+	// Throw away anything else it says so it's never stuck writing
+	go func() {
+		for range s.lines {
+		}
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		s.cmd.Wait()
+		close(exited)
+	}()
+	select {
+	case <-exited:
+	case <-time.After(grace):
+		s.cmd.Process.Kill()
+		<-exited
+	}
+}
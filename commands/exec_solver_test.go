@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Not a real test: run as a child process by the tests below, it speaks the
+// exec protocol, always answering with GC6_EXEC_REPLY. If that's "deaf" it
+// never reads anything at all.
+func TestExecHelper(t *testing.T) {
+	reply := os.Getenv("GC6_EXEC_REPLY")
+	if reply == "" {
+		return
+	}
+	defer os.Exit(0)
+	if reply == "deaf" {
+		time.Sleep(time.Hour)
+	}
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var obs execObservation
+		if err := json.Unmarshal(scanner.Bytes(), &obs); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if obs.Done {
+			return
+		}
+		if reply == "quit" {
+			return
+		}
+		fmt.Println(reply)
+	}
+}
+
+func execHelper(t *testing.T, reply string) explorer {
+	t.Setenv("GC6_EXEC_REPLY", reply)
+	return newExecSolver([]string{os.Args[0], "-test.run=^TestExecHelper$"})(nil)
+}
+
+func TestExecSolver(t *testing.T) {
+	tests := []struct {
+		reply   string
+		outcome outcome
+	}{
+		{`{"direction":"right"}`, solved},
+		{`{"direction":"sideways"}`, closedEarly},
+		{`{}`, closedEarly},
+		{"quit", closedEarly},
+	}
+	for _, test := range tests {
+		setSize(2, 1)
+		m := empty(rand.New(rand.NewSource(1)))
+		m.SetStartPoint(0, 0)
+		m.SetTreasure(1, 0)
+
+		r := solveIt(m, execHelper(t, test.reply))
+		if r.outcome != test.outcome {
+			t.Errorf("replying %s: expected %v, got %v", test.reply, test.outcome, r)
+		}
+	}
+}
+
+func TestExecSolverNames(t *testing.T) {
+	viper.Set("exec", []string{"python a.py", "/usr/bin/python b.py"})
+	defer viper.Set("exec", nil)
+
+	all, err := contestants()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{all[len(all)-2].name, all[len(all)-1].name}
+	if names[0] != "python a.py" || names[1] != "python b.py" {
+		t.Errorf("expected solvers to be named after their programs and args, got %q", names)
+	}
+
+	viper.Set("exec", []string{"python a.py", "python a.py"})
+	if _, err := contestants(); err == nil {
+		t.Error("expected solvers with the same name to be rejected")
+	}
+}
+
+func TestExecSolverStopsWritingInTime(t *testing.T) {
+	s := execHelper(t, "deaf").(*execSolver)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// It never reads, so sooner or later the pipe fills and a write blocks
+	var err error
+	for err == nil {
+		err = s.send(ctx, observation{}, false)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the write to give up when time ran out, got %v", err)
+	}
+	s.Done(observation{Err: err})
+}
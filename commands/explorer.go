@@ -0,0 +1,287 @@
+// explorer is the second version of the solver interface.
+//
+// Rather than streaming surveys and directions over channels, the harness
+// asks an explorer for one move at a time, telling it everything it knows:
+// what Icarus can see, where he is relative to where he woke up, how many
+// steps he has left, and whether his last move worked. Old style solvers
+// still work through adapt.
+
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+)
+
+// observation is what an explorer is told before each move
+type observation struct {
+	Survey    mazelib.Survey
+	Position  mazelib.Coordinate // relative to where Icarus woke up
+	Steps     int                // moves made so far, including failed ones
+	StepsLeft int                // moves left before the harness gives up
+	Err       error              // why the last move failed, or nil if it didn't
+	Victory   bool               // only ever true when passed to Done
+
+	// For a planner, every room Icarus moved into since it last planned,
+	// including this one
+	Seen plot
+}
+
+type explorer interface {
+	// Next picks the direction to move in.
+	// It must return promptly once ctx is done, and returning an error
+	// gives up on the maze.
+	Next(ctx context.Context, obs observation) (mazelib.Direction, error)
+
+	// Done is called once when the run is over, however it ended.
+	Done(obs observation)
+}
+
+// A planner is an explorer that can plan several moves at once, so they can
+// be made with a single request. explore calls Plan instead of Next.
+type planner interface {
+	explorer
+
+	// Plan picks one or more moves to make next. They're made in order
+	// until one fails or Icarus finds the treasure, and then Plan is
+	// called again.
+	Plan(ctx context.Context, obs observation) (path, error)
+}
+
+// A starter has to be started before it can move, as a child process does.
+// explore starts it before any clocks start, and as it may take a while to
+// get going, its first move is only held to time-limit, not move-timeout.
+type starter interface {
+	Start() error
+}
+
+// mover makes moves for explore, as a transport does
+type mover interface {
+	Move(dir mazelib.Direction) (mazelib.Survey, error)
+}
+
+// batchMover can make several moves at once, as mazelib.Client.Moves does
+type batchMover interface {
+	Moves(dirs []mazelib.Direction) ([]mazelib.Survey, error)
+}
+
+// moveFunc lets a function be a mover
+type moveFunc func(dir mazelib.Direction) (mazelib.Survey, error)
+
+func (f moveFunc) Move(dir mazelib.Direction) (mazelib.Survey, error) {
+	return f(dir)
+}
+
+// Make each move in turn, stopping at the first that doesn't work,
+// using a single request if the mover can.
+func moveAlong(m mover, dirs path) ([]mazelib.Survey, error) {
+	if b, ok := m.(batchMover); ok && len(dirs) > 1 {
+		return b.Moves(dirs)
+	}
+
+	surveys := make([]mazelib.Survey, 0, len(dirs))
+	for _, dir := range dirs {
+		survey, err := m.Move(dir)
+		if err != nil && err != mazelib.ErrVictory {
+			return surveys, err
+		}
+		surveys = append(surveys, survey)
+		if err != nil {
+			return surveys, err
+		}
+	}
+	return surveys, nil
+}
+
+// Returned by adapted solvers that close cmds before finding the treasure
+var errGaveUp = errors.New("solver gave up")
+
+// adapter runs an old style solver as an explorer
+type adapter struct {
+	solver  solver
+	surveys chan mazelib.Survey
+	cmds    chan mazelib.Direction
+}
+
+func adapt(s solver) explorer {
+	return &adapter{solver: s}
+}
+
+// Wraps the constructor of an old style solver for the solvers list
+func adapted(gen func() solver) solverGen {
+	return func(*bounds) explorer { return adapt(gen()) }
+}
+
+// Like adapted, for old style solvers that want to know the maze's bounds
+func adaptedInBounds(gen func(b *bounds) solver) solverGen {
+	return func(b *bounds) explorer { return adapt(gen(b)) }
+}
+
+func (a *adapter) Next(ctx context.Context, obs observation) (mazelib.Direction, error) {
+	// Old style solvers assume every move works, so they'd be lost now
+	if obs.Err != nil {
+		return 0, obs.Err
+	}
+
+	if a.cmds == nil {
+		a.surveys = make(chan mazelib.Survey, 1)
+		a.cmds = make(chan mazelib.Direction)
+		go a.solver.Solve(a.surveys, a.cmds)
+	}
+
+	a.surveys <- obs.Survey
+	select {
+	case dir, ok := <-a.cmds:
+		if !ok {
+			return 0, errGaveUp
+		}
+		return dir, nil
+	case <-ctx.Done():
+		// If we were slow to notice the move, it still counts
+		select {
+		case dir, ok := <-a.cmds:
+			if !ok {
+				return 0, errGaveUp
+			}
+			return dir, nil
+		default:
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func (a *adapter) Done(obs observation) {
+	if a.cmds == nil {
+		return
+	}
+	if errors.Is(obs.Err, context.DeadlineExceeded) {
+		abandonSolver(a.surveys, a.cmds)
+	} else {
+		stopSolver(a.surveys, a.cmds)
+	}
+}
+
+// A context that runs out after time-limit, if there is one
+func runContext() (context.Context, context.CancelFunc) {
+	if limit := viper.GetDuration("time-limit"); limit > 0 {
+		return context.WithTimeout(context.Background(), limit)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// A context that runs out after move-timeout, if there is one
+func moveContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if timeout := viper.GetDuration("move-timeout"); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// Ask for the next moves, allowing at most move-timeout for them if timed
+func nextMoves(ctx context.Context, e explorer, obs observation, timed bool) (path, error) {
+	if timed {
+		var cancel context.CancelFunc
+		ctx, cancel = moveContext(ctx)
+		defer cancel()
+	}
+
+	if p, ok := e.(planner); ok {
+		dirs, err := p.Plan(ctx, obs)
+		if err == nil && len(dirs) == 0 {
+			err = errGaveUp
+		}
+		return dirs, err
+	}
+	dir, err := e.Next(ctx, obs)
+	return path{dir}, err
+}
+
+// Drive an explorer until it finds the treasure, gives up, or runs out of
+// steps or time. m makes the moves, returning the new survey, or
+// mazelib.ErrVictory once Icarus reaches the treasure. A move that fails with
+// mazelib.ErrWall is passed on to the explorer; any other error ends the run.
+// If daedalus says Icarus is out of steps or time, that's just as if the
+// harness had said so.
+func explore(e explorer, start mazelib.Survey, m mover) (r result) {
+	_, planning := e.(planner)
+	obs := observation{Survey: start, StepsLeft: viper.GetInt("max-steps")}
+	if planning {
+		obs.Seen = plot{obs.Position: start}
+	}
+	defer func() {
+		obs.Victory = r.outcome == solved
+		if r.err != nil {
+			obs.Err = r.err
+		}
+		e.Done(obs)
+	}()
+
+	s, starting := e.(starter)
+	if starting {
+		if err := s.Start(); err != nil {
+			return result{outcome: closedEarly, err: err}
+		}
+	}
+	ctx, cancel := runContext()
+	defer cancel()
+
+	for {
+		if obs.StepsLeft <= 0 {
+			return result{outcome: gaveUp, steps: obs.Steps}
+		}
+
+		dirs, err := nextMoves(ctx, e, obs, !starting)
+		starting = false
+		switch {
+		case err == nil:
+		case errors.Is(err, context.DeadlineExceeded):
+			return result{outcome: timedOut, steps: obs.Steps, err: err}
+		case obs.Err != nil:
+			return result{outcome: illegalMove, steps: obs.Steps, err: obs.Err}
+		case err == errGaveUp:
+			return result{outcome: closedEarly, steps: obs.Steps}
+		default:
+			return result{outcome: closedEarly, steps: obs.Steps, err: err}
+		}
+		if len(dirs) > obs.StepsLeft {
+			dirs = dirs[:obs.StepsLeft]
+		}
+
+		surveys, err := moveAlong(m, dirs)
+		if planning {
+			obs.Seen = make(plot, len(surveys))
+		}
+		for i, survey := range surveys {
+			obs.Position = nextCoord(obs.Position, dirs[i])
+			obs.Survey = survey
+			if planning {
+				obs.Seen[obs.Position] = survey
+			}
+		}
+
+		moved := len(surveys)
+		switch {
+		case err == nil:
+			obs.Err = nil
+		case err == mazelib.ErrVictory:
+			return result{outcome: solved, steps: obs.Steps + moved}
+		case errors.Is(err, mazelib.ErrWall), errors.Is(err, mazelib.ErrInvalidDirection):
+			// The failed move counts as a step too
+			obs.Err = err
+			moved++
+		case errors.Is(err, mazelib.ErrOutOfTime):
+			// daedalus's clock started before ours, so it runs out first
+			return result{outcome: timedOut, steps: obs.Steps + moved, err: err}
+		case errors.Is(err, mazelib.ErrOutOfSteps):
+			return result{outcome: gaveUp, steps: obs.Steps + moved}
+		default:
+			// The move may never have happened, so don't count it
+			return result{outcome: lostContact, steps: obs.Steps + moved, err: err}
+		}
+		obs.Steps += moved
+		obs.StepsLeft -= moved
+	}
+}
@@ -0,0 +1,160 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+)
+
+// Walks into the top wall, then right to the treasure
+type bumper struct {
+	seen []observation
+	done observation
+}
+
+func (b *bumper) Next(ctx context.Context, obs observation) (mazelib.Direction, error) {
+	b.seen = append(b.seen, obs)
+	if len(b.seen) == 1 {
+		return mazelib.N, nil
+	}
+	return mazelib.E, nil
+}
+
+func (b *bumper) Done(obs observation) { b.done = obs }
+
+func TestExplorerSeesFailedMoves(t *testing.T) {
+	setSize(2, 1)
+	m := empty(rand.New(rand.NewSource(1)))
+	m.SetStartPoint(0, 0)
+	m.SetTreasure(1, 0)
+
+	b := &bumper{}
+	r := solveIt(m, b)
+	if r.outcome != solved || r.steps != 2 {
+		t.Fatalf("expected to be solved in 2 steps, got %v", r)
+	}
+	if len(b.seen) != 2 {
+		t.Fatalf("expected 2 observations, got %d", len(b.seen))
+	}
+	if b.seen[0].Err != nil {
+		t.Errorf("first observation shouldn't have an error, got %v", b.seen[0].Err)
+	}
+	if b.seen[1].Err == nil {
+		t.Error("expected to be told walking into the wall failed")
+	}
+	if b.seen[1].Position != (mazelib.Coordinate{}) {
+		t.Errorf("failed move shouldn't change position, got %v", b.seen[1].Position)
+	}
+	if b.seen[1].StepsLeft != b.seen[0].StepsLeft-1 {
+		t.Errorf("expected failed move to use up a step")
+	}
+	if !b.done.Victory {
+		t.Error("expected Done to be told of the victory")
+	}
+}
+
+// Never makes a move
+type sleeper struct{}
+
+func (sleeper) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
+	defer close(cmds)
+	for range surveys {
+		select {}
+	}
+}
+
+func TestAdaptedSolverTimesOut(t *testing.T) {
+	setSize(15, 10)
+	viper.Set("move-timeout", 10*time.Millisecond)
+	defer viper.Set("move-timeout", time.Second)
+
+	m := getSolvable(empty, rand.New(rand.NewSource(1)))
+	r := solveIt(m, adapt(sleeper{}))
+	if r.outcome != timedOut {
+		t.Errorf("expected a hung solver to time out, got %v", r)
+	}
+}
+
+func TestExploreStopsWhenMovesBreak(t *testing.T) {
+	broken := errors.New("connection refused")
+	b := &bumper{}
+	r := explore(b, mazelib.Survey{}, moveFunc(func(dir mazelib.Direction) (mazelib.Survey, error) {
+		return mazelib.Survey{}, broken
+	}))
+	if r.outcome != lostContact || r.err != broken || r.steps != 0 {
+		t.Errorf("expected to lose contact before taking a step, got %v", r)
+	}
+	if len(b.seen) != 1 {
+		t.Errorf("expected the explorer to be asked for 1 move, got %d", len(b.seen))
+	}
+}
+
+func TestExploreReadsWhyTheMazeIsOver(t *testing.T) {
+	for err, want := range map[error]outcome{
+		mazelib.ErrOutOfTime:  timedOut,
+		mazelib.ErrOutOfSteps: gaveUp,
+	} {
+		r := explore(&bumper{}, mazelib.Survey{}, moveFunc(func(dir mazelib.Direction) (mazelib.Survey, error) {
+			return mazelib.Survey{}, err
+		}))
+		if r.outcome != want {
+			t.Errorf("expected %v to end as %v, got %v", err, want, r)
+		}
+	}
+}
+
+// Counts how moves are made
+type countingMover struct {
+	moveFunc
+	moves, batches int
+}
+
+func (c *countingMover) Move(dir mazelib.Direction) (mazelib.Survey, error) {
+	c.moves++
+	return c.moveFunc(dir)
+}
+
+func (c *countingMover) Moves(dirs []mazelib.Direction) ([]mazelib.Survey, error) {
+	c.batches++
+	return moveAlong(c.moveFunc, dirs)
+}
+
+func TestPlannersMoveInBatches(t *testing.T) {
+	setSize(15, 10)
+	m := getSolvable(growingTree, rand.New(rand.NewSource(1)))
+	room, _ := m.GetRoom(m.Icarus())
+	c := &countingMover{moveFunc: func(dir mazelib.Direction) (mazelib.Survey, error) {
+		if err := m.moveDir(dir); err != nil {
+			return mazelib.Survey{}, err
+		}
+		return m.LookAround()
+	}}
+
+	r := explore(newNearest(newBounds(m.Width(), m.Height())), room.Walls, c)
+	if r.outcome != solved {
+		t.Fatalf("expected nearest to solve the maze, got %v", r)
+	}
+	if c.batches == 0 || c.moves+c.batches >= r.steps {
+		t.Errorf("expected fewer requests than steps, got %d moves and %d batches for %d steps", c.moves, c.batches, r.steps)
+	}
+}
+
+func TestAdapterCountsLateMoves(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A move that's waiting when the time runs out still counts, however
+	// select happens to pick between them
+	for i := 0; i < 100; i++ {
+		a := &adapter{surveys: make(chan mazelib.Survey, 1), cmds: make(chan mazelib.Direction, 1)}
+		a.cmds <- mazelib.E
+		if dir, err := a.Next(ctx, observation{}); err != nil || dir != mazelib.E {
+			t.Fatalf("expected the late move to count, got %v, %v", dir, err)
+		}
+	}
+}
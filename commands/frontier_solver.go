@@ -0,0 +1,121 @@
+// Explores like nearest, but rather than always heading for the closest
+// unexplored room, it scores every unexplored room on the edge of what it
+// knows and heads for the best one. A room scores better for being close,
+// for having more unexplored neighbors, and for having more unexplored space
+// around it that could still be inside the maze, going by its bounds.
+//
+// The weight given to each is read from the config, so they can be tuned
+// with shootout: frontier-distance, frontier-unknown and frontier-area,
+// along with frontier-radius, how far around a room to count unexplored space.
+package commands
+
+import (
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+)
+
+type frontierWeights struct {
+	distance float64
+	unknown  float64
+	area     float64
+	radius   int
+}
+
+type frontierSolver struct {
+	memory  plot
+	bounds  *bounds
+	pos     mazelib.Coordinate
+	path    path
+	weights frontierWeights
+}
+
+func init() {
+	viper.SetDefault("frontier-distance", 1.0)
+	viper.SetDefault("frontier-unknown", 0.5)
+	viper.SetDefault("frontier-area", 0.1)
+	viper.SetDefault("frontier-radius", 3)
+}
+
+func newFrontier(b *bounds) solver {
+	return &frontierSolver{
+		bounds: b,
+		weights: frontierWeights{
+			distance: viper.GetFloat64("frontier-distance"),
+			unknown:  viper.GetFloat64("frontier-unknown"),
+			area:     viper.GetFloat64("frontier-area"),
+			radius:   viper.GetInt("frontier-radius"),
+		},
+	}
+}
+
+func (s *frontierSolver) unexplored(c mazelib.Coordinate) bool {
+	_, explored := s.memory[c]
+	return !explored && s.bounds.Contains(c)
+}
+
+// Lower is better
+func (s *frontierSolver) score(c mazelib.Coordinate, dist int) float64 {
+	unknown := 0
+	for _, offset := range offsets {
+		if s.unexplored(mazelib.Coordinate{X: c.X + offset.X, Y: c.Y + offset.Y}) {
+			unknown++
+		}
+	}
+
+	area := 0
+	r := s.weights.radius
+	for dy := -r; dy <= r; dy++ {
+		for dx := -r; dx <= r; dx++ {
+			if abs(dx)+abs(dy) <= r && s.unexplored(mazelib.Coordinate{X: c.X + dx, Y: c.Y + dy}) {
+				area++
+			}
+		}
+	}
+
+	return s.weights.distance*float64(dist) - s.weights.unknown*float64(unknown) - s.weights.area*float64(area)
+}
+
+// Returns false once there's nowhere left to explore
+func (s *frontierSolver) nextDir() (mazelib.Direction, bool) {
+	if len(s.path) == 0 {
+		rooms, dist, directions := s.memory.Frontier(s.pos)
+
+		found := false
+		var best mazelib.Coordinate
+		var bestScore float64
+		for _, c := range rooms {
+			if !s.bounds.Contains(c) {
+				continue
+			}
+			if score := s.score(c, dist[c]); !found || score < bestScore {
+				best, bestScore, found = c, score, true
+			}
+		}
+		if !found {
+			return 0, false
+		}
+		s.path = pathTo(directions, s.pos, best)
+	}
+	next := s.path[0]
+	s.path = s.path[1:]
+	return next, true
+}
+
+func (s *frontierSolver) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
+	defer close(cmds)
+
+	s.memory = make(plot)
+
+	for survey := range surveys {
+		s.memory.Record(s.pos, survey)
+		s.bounds.Record(s.pos, survey)
+
+		newdir, ok := s.nextDir()
+		if !ok {
+			return
+		}
+
+		s.pos = nextCoord(s.pos, newdir)
+		cmds <- newdir
+	}
+}
@@ -6,15 +6,15 @@ import (
 	"github.com/fwip/gc6/mazelib"
 )
 
-func growingTree() *Maze {
-	m := fullMaze()
+func growingTree(r *rand.Rand) *Maze {
+	m := fullMaze(r)
 	m.growTree(100)
 
 	return m
 }
 
-func growingTree20() *Maze {
-	m := fullMaze()
+func growingTree20(r *rand.Rand) *Maze {
+	m := fullMaze(r)
 	m.growTree(20)
 	return m
 }
@@ -26,8 +26,8 @@ func (m *Maze) growTree(prob int) {
 	for len(toCarve) > 0 {
 
 		idx := 0
-		if rand.Intn(100) < prob {
-			idx = rand.Intn(len(toCarve))
+		if m.rng.Intn(100) < prob {
+			idx = m.rng.Intn(len(toCarve))
 		}
 		c := toCarve[idx]
 
@@ -35,7 +35,7 @@ func (m *Maze) growTree(prob int) {
 		if len(neighbors) == 0 {
 			toCarve = append(toCarve[:idx], toCarve[idx+1:]...)
 		} else {
-			n := neighbors[rand.Intn(len(neighbors))]
+			n := neighbors[m.rng.Intn(len(neighbors))]
 			m.carveTo(c, n)
 			toCarve = append(toCarve, n)
 		}
@@ -86,5 +86,5 @@ func (m *Maze) carveTo(c1, c2 mazelib.Coordinate) {
 		dir = mazelib.W
 	}
 	r1.RmWall(dir)
-	r2.RmWall(int(direction(dir).Reverse()))
+	r2.RmWall(dir.Reverse())
 }
@@ -0,0 +1,275 @@
+// daedalus's gRPC API, defined in daedaluspb/daedalus.proto, and the icarus
+// transport that uses it. It's served on --grpc-port, if one is given,
+// alongside the HTTP API, and plays by the same rules on the same maze.
+
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/fwip/gc6/daedaluspb"
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+type grpcServer struct {
+	daedaluspb.UnimplementedDaedalusServer
+}
+
+// Start listening on grpc-port, as listen does for the HTTP API
+func listenGRPC() (net.Listener, error) {
+	return net.Listen("tcp", ":"+viper.GetString("grpc-port"))
+}
+
+// Serve the gRPC API until stopServers is called, and every call has been answered
+func serveGRPC(ln net.Listener) {
+	server := grpc.NewServer()
+	daedaluspb.RegisterDaedalusServer(server, &grpcServer{})
+
+	stopped := make(chan struct{})
+	go func() {
+		<-stopping
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	if err := server.Serve(ln); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	<-stopped
+}
+
+func toPBSurvey(s mazelib.Survey) *daedaluspb.Survey {
+	return &daedaluspb.Survey{Top: s.Top, Right: s.Right, Bottom: s.Bottom, Left: s.Left}
+}
+
+func fromPBSurvey(s *daedaluspb.Survey) mazelib.Survey {
+	return mazelib.Survey{Top: s.GetTop(), Right: s.GetRight(), Bottom: s.GetBottom(), Left: s.GetLeft()}
+}
+
+func (s *grpcServer) Awake(ctx context.Context, req *daedaluspb.AwakeRequest) (*daedaluspb.MoveReply, error) {
+	survey, err := wakeIcarus("")
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &daedaluspb.MoveReply{Survey: toPBSurvey(survey)}, nil
+}
+
+func (s *grpcServer) Move(ctx context.Context, req *daedaluspb.MoveRequest) (*daedaluspb.MoveReply, error) {
+	return s.move(req)
+}
+
+// Make a move just as /move/:direction would, and translate the answer
+func (s *grpcServer) move(req *daedaluspb.MoveRequest) (*daedaluspb.MoveReply, error) {
+	m, unlock := lockCurrentMaze()
+	defer unlock()
+	if m == nil {
+		return nil, status.Error(codes.FailedPrecondition, "no maze yet, call Awake first")
+	}
+
+	code, r := moveOnce(m, req.GetRequestId(), mazelib.Direction(req.GetDirection()).String())
+	switch code {
+	case http.StatusOK:
+		return &daedaluspb.MoveReply{Survey: toPBSurvey(r.Survey), Victory: r.Victory, Message: r.Message}, nil
+	case http.StatusConflict:
+		return &daedaluspb.MoveReply{Survey: toPBSurvey(r.Survey), Wall: true, Message: r.Message}, nil
+	case http.StatusGone:
+		return nil, status.Error(codes.FailedPrecondition, r.Message)
+	}
+	return nil, status.Error(codes.InvalidArgument, r.Message)
+}
+
+func (s *grpcServer) Explore(stream daedaluspb.Daedalus_ExploreServer) error {
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		rep, err := s.move(req)
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(rep); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *grpcServer) Done(ctx context.Context, req *daedaluspb.DoneRequest) (*daedaluspb.DoneReply, error) {
+	printResults()
+
+	// GracefulStop waits for this reply to be sent
+	stopServers()
+	return &daedaluspb.DoneReply{}, nil
+}
+
+// Makes moves over an Explore stream, opened on the first Awake.
+// If daedalus takes longer than timeout to answer a move, the stream is
+// cancelled, failing the move. daedalus ends the stream itself when a move
+// can't be made, as when the maze is over, so whenever it breaks a new one
+// is opened for the next move.
+type grpcTransport struct {
+	conn         *grpc.ClientConn
+	client       daedaluspb.DaedalusClient
+	stream       daedaluspb.Daedalus_ExploreClient
+	cancelStream context.CancelFunc
+	timeout      time.Duration
+}
+
+// A transport for the daedalus given by --grpc-server, or the local one on --grpc-port
+func newGRPCTransport() (*grpcTransport, error) {
+	addr := viper.GetString("grpc-server")
+	if addr == "" {
+		if viper.GetInt("grpc-port") == 0 {
+			return nil, errors.New("no daedalus to connect to, give --grpc-server or --grpc-port")
+		}
+		addr = "127.0.0.1:" + viper.GetString("grpc-port")
+	}
+	return dialGRPC(addr, viper.GetDuration("request-timeout"))
+}
+
+func dialGRPC(addr string, timeout time.Duration) (*grpcTransport, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	return &grpcTransport{conn: conn, client: daedaluspb.NewDaedalusClient(conn), timeout: timeout}, nil
+}
+
+// A context for a single call, allowing it request-timeout
+func (t *grpcTransport) callContext() (context.Context, context.CancelFunc) {
+	if t.timeout > 0 {
+		return context.WithTimeout(context.Background(), t.timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+func (t *grpcTransport) WaitReady(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	t.conn.Connect()
+	for state := t.conn.GetState(); state != connectivity.Ready; state = t.conn.GetState() {
+		if !t.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("%v, connection is %v", ctx.Err(), state)
+		}
+	}
+	return nil
+}
+
+func (t *grpcTransport) Awake() (mazelib.Survey, error) {
+	ctx, cancel := t.callContext()
+	defer cancel()
+	rep, err := t.client.Awake(ctx, &daedaluspb.AwakeRequest{})
+	if err != nil {
+		return mazelib.Survey{}, err
+	}
+
+	if err := t.openStream(); err != nil {
+		return mazelib.Survey{}, err
+	}
+	return fromPBSurvey(rep.GetSurvey()), nil
+}
+
+// Open a new Explore stream if there isn't one
+func (t *grpcTransport) openStream() error {
+	if t.stream != nil {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := t.client.Explore(ctx)
+	if err != nil {
+		cancel()
+		return err
+	}
+	t.stream, t.cancelStream = stream, cancel
+	return nil
+}
+
+// Give up on the stream once it's broken, so the next move opens another
+func (t *grpcTransport) closeStream() {
+	t.cancelStream()
+	t.stream, t.cancelStream = nil, nil
+}
+
+func (t *grpcTransport) Move(dir mazelib.Direction) (mazelib.Survey, error) {
+	if !dir.Valid() {
+		return mazelib.Survey{}, mazelib.ErrInvalidDirection
+	}
+	if err := t.openStream(); err != nil {
+		return mazelib.Survey{}, err
+	}
+	defer t.deadline()()
+
+	// A failed Send only says the stream is broken; Recv says why
+	err := t.stream.Send(&daedaluspb.MoveRequest{Direction: daedaluspb.Direction(dir)})
+	var rep *daedaluspb.MoveReply
+	if err == nil {
+		rep, err = t.stream.Recv()
+	} else if err == io.EOF {
+		_, err = t.stream.Recv()
+	}
+	switch {
+	case err != nil:
+		t.closeStream()
+		if st, ok := status.FromError(err); ok && st.Code() == codes.FailedPrecondition {
+			if over := mazelib.MazeOverError(st.Message()); over != nil {
+				return mazelib.Survey{}, over
+			}
+		}
+		return mazelib.Survey{}, err
+	case rep.GetWall():
+		return mazelib.Survey{}, mazelib.ErrWall
+	case rep.GetVictory():
+		return fromPBSurvey(rep.GetSurvey()), mazelib.ErrVictory
+	}
+	return fromPBSurvey(rep.GetSurvey()), nil
+}
+
+// Cancel the stream if it isn't done within timeout.
+// Call the returned func once it is.
+func (t *grpcTransport) deadline() (stop func()) {
+	if t.timeout <= 0 {
+		return func() {}
+	}
+	timer := time.AfterFunc(t.timeout, t.cancelStream)
+	return func() { timer.Stop() }
+}
+
+func (t *grpcTransport) Done() error {
+	defer t.conn.Close()
+
+	// Let daedalus finish with the stream, or it can't stop
+	if t.stream != nil {
+		stop := t.deadline()
+		t.stream.CloseSend()
+		for {
+			if _, err := t.stream.Recv(); err != nil {
+				break
+			}
+		}
+		stop()
+		t.closeStream()
+	}
+
+	ctx, cancel := t.callContext()
+	defer cancel()
+	_, err := t.client.Done(ctx, &daedaluspb.DoneRequest{})
+	return err
+}
@@ -1,14 +1,12 @@
 package commands
 
 import (
-	"errors"
 	"fmt"
-	"math/rand"
 
 	"github.com/fwip/gc6/mazelib"
 )
 
-type path []int
+type path []mazelib.Direction
 
 type plot map[mazelib.Coordinate]mazelib.Survey
 
@@ -17,7 +15,13 @@ func (p *plot) Record(c mazelib.Coordinate, s mazelib.Survey) {
 }
 
 func (p *plot) ShortestPathToUnexplored(start mazelib.Coordinate) path {
-	directions := make(map[mazelib.Coordinate]int)
+	return p.ShortestPathAvoiding(start, nil)
+}
+
+// Like ShortestPathToUnexplored, but never walks through rooms for which avoid
+// returns true. A nil avoid doesn't avoid anywhere.
+func (p *plot) ShortestPathAvoiding(start mazelib.Coordinate, avoid func(mazelib.Coordinate) bool) path {
+	directions := make(map[mazelib.Coordinate]mazelib.Direction)
 	queue := []mazelib.Coordinate{start}
 
 	current := start
@@ -32,6 +36,9 @@ func (p *plot) ShortestPathToUnexplored(start mazelib.Coordinate) path {
 		dirs := validDirections(svy)
 		for _, dir := range dirs {
 			n := nextCoord(current, dir)
+			if avoid != nil && avoid(n) {
+				continue
+			}
 			if _, visited := directions[n]; !visited {
 				directions[n] = dir
 				queue = append(queue, n)
@@ -40,11 +47,47 @@ func (p *plot) ShortestPathToUnexplored(start mazelib.Coordinate) path {
 		queue = queue[1:]
 	}
 
-	path := []int{}
-	for current != start {
+	return pathTo(directions, start, current)
+}
+
+// Finds every unexplored room reachable from start, nearest first.
+// dist holds how many steps away each room is, and directions the way
+// into each room on the way there, for use with pathTo.
+func (p *plot) Frontier(start mazelib.Coordinate) (rooms []mazelib.Coordinate, dist map[mazelib.Coordinate]int, directions map[mazelib.Coordinate]mazelib.Direction) {
+	dist = map[mazelib.Coordinate]int{start: 0}
+	directions = make(map[mazelib.Coordinate]mazelib.Direction)
+	queue := []mazelib.Coordinate{start}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		svy, explored := (*p)[current]
+
+		if !explored {
+			rooms = append(rooms, current)
+			continue
+		}
+
+		for _, dir := range validDirections(svy) {
+			n := nextCoord(current, dir)
+			if _, visited := dist[n]; !visited {
+				dist[n] = dist[current] + 1
+				directions[n] = dir
+				queue = append(queue, n)
+			}
+		}
+	}
+
+	return rooms, dist, directions
+}
+
+// Walk back from end to start along the directions found by a search
+func pathTo(directions map[mazelib.Coordinate]mazelib.Direction, start, end mazelib.Coordinate) path {
+	path := path{}
+	for current := end; current != start; {
 		dir := directions[current]
-		path = append([]int{dir}, path...)
-		current = nextCoord(current, int(direction(dir).Reverse()))
+		path = append([]mazelib.Direction{dir}, path...)
+		current = nextCoord(current, dir.Reverse())
 	}
 
 	return path
@@ -54,23 +97,13 @@ func isJunction(svy mazelib.Survey) bool {
 	return len(validDirections(svy)) > 2
 }
 
-func nextCoord(c mazelib.Coordinate, direction int) mazelib.Coordinate {
-	out := mazelib.Coordinate{X: c.X, Y: c.Y}
-	switch direction {
-	case mazelib.N:
-		out.Y--
-	case mazelib.S:
-		out.Y++
-	case mazelib.E:
-		out.X++
-	case mazelib.W:
-		out.X--
-	}
-	return out
+func nextCoord(c mazelib.Coordinate, dir mazelib.Direction) mazelib.Coordinate {
+	d := dir.Delta()
+	return mazelib.Coordinate{X: c.X + d.X, Y: c.Y + d.Y}
 }
 
-func validDirections(s mazelib.Survey) []int {
-	adjacent := make([]int, 0, 4)
+func validDirections(s mazelib.Survey) []mazelib.Direction {
+	adjacent := make([]mazelib.Direction, 0, 4)
 	if !s.Top {
 		adjacent = append(adjacent, mazelib.N)
 	}
@@ -86,7 +119,7 @@ func validDirections(s mazelib.Survey) []int {
 	return adjacent
 }
 
-func canGo(s mazelib.Survey, dir int) bool {
+func canGo(s mazelib.Survey, dir mazelib.Direction) bool {
 	switch dir {
 	case mazelib.N:
 		return !s.Top
@@ -100,6 +133,26 @@ func canGo(s mazelib.Survey, dir int) bool {
 	return false
 }
 
+// The direction to the right of someone facing dir
+func turnRight(dir mazelib.Direction) mazelib.Direction {
+	switch dir {
+	case mazelib.N:
+		return mazelib.E
+	case mazelib.E:
+		return mazelib.S
+	case mazelib.S:
+		return mazelib.W
+	case mazelib.W:
+		return mazelib.N
+	}
+	panic("Not a direction")
+}
+
+// The direction to the left of someone facing dir
+func turnLeft(dir mazelib.Direction) mazelib.Direction {
+	return turnRight(dir).Reverse()
+}
+
 func (m *Maze) getAdjacent(c mazelib.Coordinate) []mazelib.Coordinate {
 	adjacent := make([]mazelib.Coordinate, 0, 4)
 	room := m.rooms[c.Y][c.X]
@@ -134,7 +187,7 @@ func numWalls(r *mazelib.Room) int {
 	return 4 - len(validDirections(r.Walls))
 }
 
-func (m *Maze) addWall(c mazelib.Coordinate, dir int) (ok bool) {
+func (m *Maze) addWall(c mazelib.Coordinate, dir mazelib.Direction) (ok bool) {
 
 	c2 := nextCoord(c, dir)
 	r1, err1 := m.GetRoom(c.X, c.Y)
@@ -145,7 +198,7 @@ func (m *Maze) addWall(c mazelib.Coordinate, dir int) (ok bool) {
 		return false
 	}
 	r1.AddWall(dir)
-	r2.AddWall(int(direction(dir).Reverse()))
+	r2.AddWall(dir.Reverse())
 	return true
 }
 
@@ -163,7 +216,7 @@ func (m *Maze) addBounds() {
 }
 
 func (m *Maze) randCoord() mazelib.Coordinate {
-	return mazelib.Coordinate{X: rand.Intn(m.Width()), Y: rand.Intn(m.Height())}
+	return mazelib.Coordinate{X: m.rng.Intn(m.Width()), Y: m.rng.Intn(m.Height())}
 }
 
 func (m *Maze) placeRandomly() {
@@ -203,7 +256,7 @@ func (m *Maze) getRoomAt(c mazelib.Coordinate) (*mazelib.Room, error) {
 	return m.GetRoom(c.X, c.Y)
 }
 
-func (m *Maze) moveDir(dir int) error {
+func (m *Maze) moveDir(dir mazelib.Direction) error {
 	switch dir {
 	case mazelib.N:
 		return m.MoveUp()
@@ -214,5 +267,5 @@ func (m *Maze) moveDir(dir int) error {
 	case mazelib.W:
 		return m.MoveLeft()
 	}
-	return errors.New("Invalid direction")
+	return mazelib.ErrInvalidDirection
 }
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/fwip/gc6/mazelib"
+)
+
+// A plot of the whole maze except for one room in the far corner,
+// so the search has to cover nearly everything.
+func explored(m *Maze) plot {
+	p := make(plot)
+	for y := 0; y < m.Height(); y++ {
+		for x := 0; x < m.Width(); x++ {
+			p.Record(mazelib.Coordinate{X: x, Y: y}, m.rooms[y][x].Walls)
+		}
+	}
+	delete(p, mazelib.Coordinate{X: m.Width() - 1, Y: m.Height() - 1})
+	return p
+}
+
+func TestShortestPathToUnexplored(t *testing.T) {
+	setSize(15, 10)
+	m := empty(rand.New(rand.NewSource(1)))
+	p := explored(m)
+
+	path := p.ShortestPathToUnexplored(mazelib.Coordinate{})
+	if len(path) != 14+9 {
+		t.Fatalf("expected a path of %d steps, got %d", 14+9, len(path))
+	}
+	c := mazelib.Coordinate{}
+	for _, dir := range path {
+		if !canGo(p[c], dir) {
+			t.Fatalf("path walks through a wall at %v", c)
+		}
+		c = nextCoord(c, dir)
+	}
+	if _, ok := p[c]; ok {
+		t.Errorf("path ends at %v, which is already explored", c)
+	}
+}
+
+func BenchmarkShortestPathToUnexplored(b *testing.B) {
+	setSize(50, 50)
+	p := explored(growingTree(rand.New(rand.NewSource(1))))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		p.ShortestPathToUnexplored(mazelib.Coordinate{})
+	}
+}
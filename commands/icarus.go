@@ -16,26 +16,18 @@
 package commands
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
-	"net/http"
+	"os"
+	"time"
 
 	"github.com/fwip/gc6/mazelib"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var dirName = map[int]string{
-	mazelib.N: "up",
-	mazelib.S: "down",
-	mazelib.W: "left",
-	mazelib.E: "right",
-}
-
 type solver interface {
-	Solve(<-chan mazelib.Survey, chan<- int)
+	Solve(<-chan mazelib.Survey, chan<- mazelib.Direction)
 }
 
 // Defining the icarus command.
@@ -49,119 +41,132 @@ var icarusCmd = &cobra.Command{
   there is a wall or not to the top, right, bottom and left. He takes one step
   and then can discover if his new cell has walls on each of the four sides.
 
-  Icarus can connect to a Daedalus and solve many laybrinths at a time.`,
+  Icarus can connect to a Daedalus and solve many laybrinths at a time.
+  By default he looks for one on this machine, but --server points him
+  at one anywhere else. With --transport inproc he skips Daedalus and
+  solves mazes of his own instead.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		RunIcarus()
+		t, err := pickTransport(viper.GetString("transport"), "http")
+		if err == nil {
+			err = RunIcarus(t)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
 func init() {
+	icarusCmd.Flags().String("server", "", "Base URL of the daedalus to connect to (default is http://127.0.0.1 on --port)")
+	icarusCmd.Flags().String("grpc-server", "", "Address of the daedalus to connect to with --transport grpc (default is 127.0.0.1 on --grpc-port)")
+	icarusCmd.Flags().String("host", "", "Host header to send to daedalus, if not the one in --server")
+	icarusCmd.Flags().Duration("request-timeout", 10*time.Second, "Maximum time to wait for daedalus to answer a request (0 for no limit)")
+
+	icarusCmd.Flags().Duration("wait", 0, "Time to wait for daedalus to be ready before starting (0 to not wait)")
+	icarusCmd.Flags().Int("retries", 5, "Times to retry a request when daedalus can't be reached")
+	icarusCmd.Flags().Duration("retry-backoff", 100*time.Millisecond, "Time to wait before the first retry, doubling for each one after")
+
+	viper.BindPFlag("server", icarusCmd.Flags().Lookup("server"))
+	viper.BindPFlag("grpc-server", icarusCmd.Flags().Lookup("grpc-server"))
+	viper.BindPFlag("host", icarusCmd.Flags().Lookup("host"))
+	viper.BindPFlag("request-timeout", icarusCmd.Flags().Lookup("request-timeout"))
+	viper.BindPFlag("wait", icarusCmd.Flags().Lookup("wait"))
+	viper.BindPFlag("retries", icarusCmd.Flags().Lookup("retries"))
+	viper.BindPFlag("retry-backoff", icarusCmd.Flags().Lookup("retry-backoff"))
+
 	RootCmd.AddCommand(icarusCmd)
 }
 
-func RunIcarus() {
-	// Run the solver as many times as the user desires.
-	fmt.Println("Solving", viper.GetInt("times"), "times")
-	for x := 0; x < viper.GetInt("times"); x++ {
+// Solve as many mazes as asked, reporting each one that isn't solved.
+// It returns an error if any of them weren't.
+func RunIcarus(t transport) error {
+	gen, err := pickSolver(viper.GetString("solver"))
+	if err != nil {
+		return err
+	}
+	if w, ok := t.(waiter); ok {
+		if wait := viper.GetDuration("wait"); wait > 0 {
+			if err := w.WaitReady(wait); err != nil {
+				return fmt.Errorf("daedalus isn't ready: %v", err)
+			}
+		}
+	}
 
-		solveMaze()
+	// Run the solver as many times as the user desires.
+	times := viper.GetInt("times")
+	fmt.Println("Solving", times, "times")
+	failed := 0
+	for x := 0; x < times; x++ {
+		if err := solveMaze(t, gen); err != nil {
+			fmt.Printf("Run %d failed: %v\n", x+1, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("%d of %d runs failed\n", failed, times)
 	}
 
 	// Once we have solved the maze the required times, tell daedalus we are done
-	makeRequest("http://127.0.0.1:" + viper.GetString("port") + "/done")
+	if err := t.Done(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d runs failed", failed, times)
+	}
+	return nil
 }
 
-// Make a call to the laybrinth server (daedalus) that icarus is ready to wake up
-func awake() mazelib.Survey {
-	contents, err := makeRequest("http://127.0.0.1:" + viper.GetString("port") + "/awake")
-	if err != nil {
-		fmt.Println(err)
+// A client for the daedalus given by --server, or the local one on --port
+func newClient() *mazelib.Client {
+	base := viper.GetString("server")
+	if base == "" {
+		base = "http://127.0.0.1:" + viper.GetString("port")
 	}
-	r := ToReply(contents)
-	return r.Survey
+	c := mazelib.NewClient(base)
+	c.Host = viper.GetString("host")
+	c.Timeout = viper.GetDuration("request-timeout")
+	c.Retries = viper.GetInt("retries")
+	c.Backoff = viper.GetDuration("retry-backoff")
+	return c
 }
 
-// Make a call to the laybrinth server (daedalus)
-// to move Icarus a given direction
-// Will be used heavily by solveMaze
-func Move(direction string) (mazelib.Survey, error) {
-	if direction == "left" || direction == "right" || direction == "up" || direction == "down" {
-
-		contents, err := makeRequest("http://127.0.0.1:" + viper.GetString("port") + "/move/" + direction)
-		if err != nil {
-			return mazelib.Survey{}, err
-		}
+// Wake up in a new maze and try to solve it
+func solveMaze(t transport, gen solverGen) error {
+	start, err := t.Awake()
+	if err != nil {
+		return err
+	}
 
-		rep := ToReply(contents)
-		if rep.Victory == true {
-			fmt.Println(rep.Message)
-			return rep.Survey, mazelib.ErrVictory
-		} else {
-			return rep.Survey, errors.New(rep.Message)
-		}
+	// Only mazes made here are sure to be the size we've been told.
+	// A daedalus elsewhere could be making mazes of any size.
+	b := newBounds(0, 0)
+	if _, ok := t.(inproc); ok {
+		b = newBounds(viper.GetInt("width"), viper.GetInt("height"))
 	}
 
-	return mazelib.Survey{}, errors.New("invalid direction")
+	r := explore(gen(b), start, t)
+	switch r.outcome {
+	case solved:
+		fmt.Printf("Victory achieved in %d steps\n", r.steps)
+		return nil
+	case gaveUp:
+		return fmt.Errorf("reached max-steps (%d)", viper.GetInt("max-steps"))
+	default:
+		return errors.New(r.String())
+	}
 }
 
-// utility function to wrap making requests to the daedalus server
-func makeRequest(url string) ([]byte, error) {
-	response, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer response.Body.Close()
-	contents, err := ioutil.ReadAll(response.Body)
+// Find the solver picked with --solver, built in or external
+func pickSolver(name string) (solverGen, error) {
+	all, err := contestants()
 	if err != nil {
 		return nil, err
 	}
-	return contents, nil
-}
-
-// Handling a JSON response and unmarshalling it into a reply struct
-func ToReply(in []byte) mazelib.Reply {
-	res := &mazelib.Reply{}
-	json.Unmarshal(in, &res)
-	return *res
-}
-
-func runSolver(s solver) {
-	surveys := make(chan mazelib.Survey, 1)
-	cmds := make(chan int)
-	defer close(surveys)
-
-	var err error
-	survey := awake()
-	surveys <- survey
-	go s.Solve(surveys, cmds)
-
-	maxSteps := viper.GetInt("max-steps")
-	steps := 0
-
-	for dir := range cmds {
-		name, ok := dirName[dir]
-		if !ok {
-			fmt.Println("Solver returned", dir, ", not N S E W (1-4)")
-			return
-		}
-
-		survey, err = Move(name)
-
-		if err.Error() != "" {
-			fmt.Println("Error!", err)
-			return
+	for _, s := range all {
+		if s.name == name {
+			return s.solver, nil
 		}
-		surveys <- survey
-		steps++
-		if steps > maxSteps {
-			fmt.Printf("Reached max-steps (%d), halting\n", maxSteps)
-			return
-		}
-
 	}
-
-}
-
-func solveMaze() {
-	runSolver(&nearest{})
+	return nil, fmt.Errorf("no solver named %q", name)
 }
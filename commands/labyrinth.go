@@ -22,6 +22,7 @@ package commands
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -34,8 +35,9 @@ var CfgFile string
 
 // Defining the daedalus command.
 // This will be called as 'laybrinth'
-// The default behavior will be to run both a server (daedalus)
-// and a client (icarus) and connect them to each other.
+// The default behavior will be to run a client (icarus) against mazes
+// made in-process, or with --transport http, to run both a server
+// (daedalus) and a client and connect them to each other.
 var RootCmd = &cobra.Command{
 	Use:   "labyrinth",
 	Short: "a labyrinth generator and solver",
@@ -51,13 +53,45 @@ if there is a wall or not to the top, right, bottom and left. He takes
 one step and then can discover if his new cell has walls on each of
 the four sides.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		go RunServer()
-
-		// give server time to start before sending a request.
-		// There's a better way to do this, but I'm lazy and this is just for fun.
-		time.Sleep(1 * time.Second)
-
-		RunIcarus()
+		var t transport
+		var err error
+
+		// Once we're listening, icarus's requests will be answered
+		// as soon as the server gets going, so there's no need to wait.
+		switch name := viper.GetString("transport"); name {
+		case "", "inproc":
+			t = inproc{}
+		case "grpc":
+			ln, err := listenGRPC()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			go serveGRPC(ln)
+
+			// Without --grpc-port it's on whatever port was free
+			t, err = dialGRPC(ln.Addr().String(), viper.GetDuration("request-timeout"))
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		default:
+			if t, err = pickTransport(name, "inproc"); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			ln, err := listen()
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			go serve(ln)
+		}
+
+		if err := RunIcarus(t); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -68,17 +102,31 @@ func init() {
 	// by the indidual behaviors of icarus and daedalus
 	RootCmd.PersistentFlags().StringVar(&CfgFile, "config", "", "config file (default is $CWD/config.yaml)")
 	RootCmd.PersistentFlags().IntP("port", "p", 8013, "Port run on")
+	RootCmd.PersistentFlags().Int("grpc-port", 0, "Port to serve the gRPC API on (default 0, not served)")
 	RootCmd.PersistentFlags().IntP("width", "x", 15, "width of the laybrinth")
 	RootCmd.PersistentFlags().IntP("height", "y", 10, "height of the laybrinth") // 'h' is used for help already
 	RootCmd.PersistentFlags().IntP("times", "t", 1, "times to solve the laybrinth")
 	RootCmd.PersistentFlags().IntP("max-steps", "m", 500, "Maximum steps before giving up")
+	RootCmd.PersistentFlags().Duration("move-timeout", time.Second, "Maximum time a solver may take to pick a move (0 for no limit)")
+	RootCmd.PersistentFlags().Duration("time-limit", time.Minute, "Maximum time a solver may take for a whole run (0 for no limit)")
+	RootCmd.PersistentFlags().Int64("seed", 0, "Seed for generating benchmark mazes (default is random)")
+	RootCmd.PersistentFlags().StringArray("exec", nil, "Command line of an external solver to compete (can be repeated)")
+	RootCmd.PersistentFlags().String("transport", "", "How icarus gets to the maze, inproc, http, stream, v2 or grpc (default inproc, or http for the icarus command)")
+	RootCmd.PersistentFlags().String("solver", "nearest", "Solver for icarus to use, by name (external solvers are named after their command line)")
 
 	// Bind viper to these flags so viper can read flag values along with config, env, etc.
 	viper.BindPFlag("width", RootCmd.PersistentFlags().Lookup("width"))
 	viper.BindPFlag("height", RootCmd.PersistentFlags().Lookup("height"))
 	viper.BindPFlag("port", RootCmd.PersistentFlags().Lookup("port"))
+	viper.BindPFlag("grpc-port", RootCmd.PersistentFlags().Lookup("grpc-port"))
 	viper.BindPFlag("times", RootCmd.PersistentFlags().Lookup("times"))
 	viper.BindPFlag("max-steps", RootCmd.PersistentFlags().Lookup("max-steps"))
+	viper.BindPFlag("move-timeout", RootCmd.PersistentFlags().Lookup("move-timeout"))
+	viper.BindPFlag("time-limit", RootCmd.PersistentFlags().Lookup("time-limit"))
+	viper.BindPFlag("seed", RootCmd.PersistentFlags().Lookup("seed"))
+	viper.BindPFlag("exec", RootCmd.PersistentFlags().Lookup("exec"))
+	viper.BindPFlag("transport", RootCmd.PersistentFlags().Lookup("transport"))
+	viper.BindPFlag("solver", RootCmd.PersistentFlags().Lookup("solver"))
 }
 
 // Read in config file and ENV variables if set.
@@ -91,7 +139,8 @@ func initConfig() {
 	viper.SetConfigName("config") // name of config file (without extension)
 	viper.AddConfigPath(dir)
 
-	viper.AutomaticEnv() // read in environment variables that match
+	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_")) // so MAX_STEPS sets max-steps
+	viper.AutomaticEnv()                                   // read in environment variables that match
 
 	// If a config.yaml file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
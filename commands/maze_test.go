@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+)
+
+var mazeSizes = []struct{ width, height int }{
+	{2, 2},
+	{15, 10},
+	{50, 50},
+	{100, 100},
+}
+
+func setSize(width, height int) {
+	viper.Set("width", width)
+	viper.Set("height", height)
+}
+
+// Every wall must be seen from both sides.
+func checkTwoWayWalls(t *testing.T, m *Maze) {
+	for y := 0; y < m.Height(); y++ {
+		for x := 0; x < m.Width(); x++ {
+			r := m.rooms[y][x]
+			if x+1 < m.Width() && r.Walls.Right != m.rooms[y][x+1].Walls.Left {
+				t.Errorf("one way wall between (%d,%d) and (%d,%d)", x, y, x+1, y)
+			}
+			if y+1 < m.Height() && r.Walls.Bottom != m.rooms[y+1][x].Walls.Top {
+				t.Errorf("one way wall between (%d,%d) and (%d,%d)", x, y, x, y+1)
+			}
+		}
+	}
+}
+
+// Every room on the edge must be walled off from the outside.
+func checkBounded(t *testing.T, m *Maze) {
+	for x := 0; x < m.Width(); x++ {
+		if !m.rooms[0][x].Walls.Top {
+			t.Errorf("no wall above (%d,0)", x)
+		}
+		if !m.rooms[m.Height()-1][x].Walls.Bottom {
+			t.Errorf("no wall below (%d,%d)", x, m.Height()-1)
+		}
+	}
+	for y := 0; y < m.Height(); y++ {
+		if !m.rooms[y][0].Walls.Left {
+			t.Errorf("no wall left of (0,%d)", y)
+		}
+		if !m.rooms[y][m.Width()-1].Walls.Right {
+			t.Errorf("no wall right of (%d,%d)", m.Width()-1, y)
+		}
+	}
+}
+
+// The treasure must be reachable from the start, walking only through open walls.
+func checkSolvable(t *testing.T, m *Maze) {
+	if m.start == m.end {
+		t.Fatalf("start and treasure are both at %v", m.start)
+	}
+	seen := map[mazelib.Coordinate]bool{m.start: true}
+	queue := []mazelib.Coordinate{m.start}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if c == m.end {
+			return
+		}
+		for _, dir := range validDirections(m.rooms[c.Y][c.X].Walls) {
+			n := nextCoord(c, dir)
+			if !seen[n] {
+				seen[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	t.Errorf("treasure at %v can't be reached from %v", m.end, m.start)
+}
+
+func TestGeneratedMazes(t *testing.T) {
+	for _, g := range gens {
+		for _, size := range mazeSizes[:3] {
+			t.Run(fmt.Sprintf("%s/%dx%d", g.name, size.width, size.height), func(t *testing.T) {
+				setSize(size.width, size.height)
+				for seed := int64(1); seed <= 20; seed++ {
+					m := getSolvable(g.gen, rand.New(rand.NewSource(seed)))
+					checkTwoWayWalls(t, m)
+					checkBounded(t, m)
+					checkSolvable(t, m)
+				}
+			})
+		}
+	}
+}
+
+func TestSameSeedSameMaze(t *testing.T) {
+	setSize(15, 10)
+	for _, g := range gens {
+		a := getSolvable(g.gen, rand.New(rand.NewSource(42)))
+		b := getSolvable(g.gen, rand.New(rand.NewSource(42)))
+		if fmt.Sprint(a.rooms, a.start, a.end) != fmt.Sprint(b.rooms, b.start, b.end) {
+			t.Errorf("%s generated different mazes from the same seed", g.name)
+		}
+	}
+}
+
+func BenchmarkGenerators(b *testing.B) {
+	for _, g := range gens {
+		for _, size := range mazeSizes[1:] {
+			b.Run(fmt.Sprintf("%s/%dx%d", g.name, size.width, size.height), func(b *testing.B) {
+				setSize(size.width, size.height)
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					g.gen(rand.New(rand.NewSource(int64(i))))
+				}
+			})
+		}
+	}
+}
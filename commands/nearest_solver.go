@@ -1,38 +1,66 @@
 // This keeps track of all the unexplored (accessible) rooms, and at each step just runs toward whichever is closest
+//
+// Every step of the way there is through rooms it already knows, so it plans
+// the whole path at once, letting it be walked with a single request.
 package commands
 
-import "github.com/fwip/gc6/mazelib"
+import (
+	"context"
+
+	"github.com/fwip/gc6/mazelib"
+)
 
 type nearest struct {
 	memory plot
-	pos    mazelib.Coordinate
+	bounds *bounds
 	path   path
 }
 
-func newNearest() solver {
-	return &nearest{}
+func newNearest(b *bounds) explorer {
+	return &nearest{memory: make(plot), bounds: b}
 }
 
-func (s *nearest) nextDir(survey mazelib.Survey) int {
-	if len(s.path) == 0 {
-		s.path = s.memory.ShortestPathToUnexplored(s.pos)
+// Remember the rooms we've seen since we last planned
+func (s *nearest) record(obs observation) {
+	s.memory.Record(obs.Position, obs.Survey)
+	s.bounds.Record(obs.Position, obs.Survey)
+	for c, survey := range obs.Seen {
+		s.memory.Record(c, survey)
+		s.bounds.Record(c, survey)
 	}
-	next := s.path[0]
-	s.path = s.path[1:]
-	return next
 }
 
-func (s *nearest) Solve(surveys <-chan mazelib.Survey, cmds chan<- int) {
-	defer close(cmds)
-
-	s.memory = make(map[mazelib.Coordinate]mazelib.Survey)
+func (s *nearest) Plan(ctx context.Context, obs observation) (path, error) {
+	// We never walk into walls, so the maze must have changed under us
+	if obs.Err != nil {
+		return nil, obs.Err
+	}
+	s.record(obs)
 
-	for survey := range surveys {
-		s.memory[s.pos] = survey
+	if len(s.path) == 0 {
+		s.path = s.memory.ShortestPathAvoiding(obs.Position, s.bounds.Outside)
+	}
+	plan := s.path
+	s.path = nil
+	return plan, nil
+}
 
-		newdir := s.nextDir(survey)
+func (s *nearest) Next(ctx context.Context, obs observation) (mazelib.Direction, error) {
+	if obs.Err != nil {
+		return 0, obs.Err
+	}
+	s.record(obs)
 
-		s.pos = nextCoord(s.pos, newdir)
-		cmds <- newdir
+	if len(s.path) == 0 {
+		s.path = s.memory.ShortestPathAvoiding(obs.Position, s.bounds.Outside)
+	}
+	// Everywhere we can reach is explored, and there's no treasure
+	if len(s.path) == 0 {
+		return 0, errGaveUp
 	}
+	next := s.path[0]
+	s.path = s.path[1:]
+	return next, nil
 }
+
+func (s *nearest) Done(obs observation) {}
@@ -6,7 +6,7 @@ import "github.com/fwip/gc6/mazelib"
 
 type noop struct{}
 
-func (s noop) Solve(surveys <-chan mazelib.Survey, cmds chan<- int) {
+func (s noop) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
 	close(cmds)
 }
 
@@ -0,0 +1,103 @@
+// The oracle sees the whole maze, so it can say how well a solver could
+// possibly have done. Shootout prints its numbers next to the solvers'.
+//
+// bfs is the shortest path from Icarus to the treasure. Nothing can beat it,
+// but only a solver that already knew where the treasure was could match it.
+//
+// bound is a lower bound on the average steps taken by a solver that knows
+// the layout of the maze, but not where the treasure is. The treasure is
+// equally likely to be in any room reachable from the start, and the i'th new
+// room a solver reaches can't be reached in fewer than i steps, or in fewer
+// than the distance to the i'th nearest room. A solver that can't see the
+// maze can only do worse.
+
+package commands
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/fwip/gc6/mazelib"
+)
+
+type reference struct {
+	shortest []int
+	bound    []float64
+}
+
+func (r reference) avgShortest() int {
+	return mazelib.AvgScores(r.shortest)
+}
+
+func (r reference) avgBound() int {
+	if len(r.bound) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, b := range r.bound {
+		total += b
+	}
+	return int(total / float64(len(r.bound)))
+}
+
+// Work out the reference numbers for the same mazes fight would use
+func referee(gen mazeGen, seed int64, times int) reference {
+	var r reference
+	for i := 0; i < times; i++ {
+		m := getSolvable(gen, rand.New(rand.NewSource(seed+int64(i))))
+		shortest, bound := oracle(m)
+		r.shortest = append(r.shortest, shortest)
+		r.bound = append(r.bound, bound)
+	}
+	return r
+}
+
+func references(gens []namedGen, seed int64, times int) []reference {
+	refs := make([]reference, len(gens))
+	for i := range gens {
+		refs[i] = referee(gens[i].gen, seed, times)
+	}
+	return refs
+}
+
+func oracle(m *Maze) (shortest int, bound float64) {
+	dist := m.distances(m.start)
+	shortest = dist[m.end]
+
+	var rooms []int
+	for c, d := range dist {
+		if c != m.start {
+			rooms = append(rooms, d)
+		}
+	}
+	if len(rooms) == 0 {
+		return shortest, 0
+	}
+	sort.Ints(rooms)
+
+	total := 0
+	for i, d := range rooms {
+		if d < i+1 {
+			d = i + 1
+		}
+		total += d
+	}
+	return shortest, float64(total) / float64(len(rooms))
+}
+
+// The number of steps from start to every room that can be reached from it
+func (m *Maze) distances(start mazelib.Coordinate) map[mazelib.Coordinate]int {
+	dist := map[mazelib.Coordinate]int{start: 0}
+	queue := []mazelib.Coordinate{start}
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, n := range m.getAdjacent(c) {
+			if _, seen := dist[n]; !seen {
+				dist[n] = dist[c] + 1
+				queue = append(queue, n)
+			}
+		}
+	}
+	return dist
+}
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/fwip/gc6/mazelib"
+)
+
+func TestOracle(t *testing.T) {
+	// An open 3x1 corridor, starting at one end with the treasure at the other
+	setSize(3, 1)
+	m := empty(rand.New(rand.NewSource(1)))
+	m.start = mazelib.Coordinate{X: 0, Y: 0}
+	m.end = mazelib.Coordinate{X: 2, Y: 0}
+
+	shortest, bound := oracle(m)
+	if shortest != 2 {
+		t.Errorf("expected the treasure to be 2 steps away, got %d", shortest)
+	}
+	if bound != 1.5 {
+		t.Errorf("expected a bound of 1.5 steps, got %v", bound)
+	}
+}
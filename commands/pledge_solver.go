@@ -0,0 +1,107 @@
+// The Pledge algorithm picks a preferred direction and walks that way until it
+// hits a wall. It then follows the wall with its right hand, counting how far
+// it has turned, and only lets go once it has turned all the way back to
+// facing the preferred direction. Counting the turns stops it from getting
+// stuck circling an island, which plain wall followers can't avoid.
+//
+// There's no exit to escape to here, so if it ends up going round in circles
+// anyway it tries the next preferred direction, and gives up after all four.
+package commands
+
+import "github.com/fwip/gc6/mazelib"
+
+type pledge struct {
+	pos       mazelib.Coordinate
+	dir       mazelib.Direction
+	preferred mazelib.Direction
+	turns     int // quarter turns to the right of preferred
+	following bool
+	tries     int
+	seen      map[heading]int
+}
+
+func newPledge() solver {
+	return &pledge{}
+}
+
+// The direction after making some quarter turns to the right from dir.
+// Negative turns are to the left.
+func rotate(dir mazelib.Direction, turns int) mazelib.Direction {
+	for turns = (turns%4 + 4) % 4; turns > 0; turns-- {
+		dir = turnRight(dir)
+	}
+	return dir
+}
+
+func (s *pledge) nextDir(survey mazelib.Survey) mazelib.Direction {
+	// Right hand on the wall: right, straight, left, then back
+	order := []int{1, 0, -1, -2}
+	if !s.following {
+		if canGo(survey, s.dir) {
+			return s.dir
+		}
+		// We've just walked into a wall, so turn left to put it on our right
+		s.following = true
+		order = []int{-1, 1, -2}
+	}
+
+	for _, turn := range order {
+		if dir := rotate(s.dir, turn); canGo(survey, dir) {
+			s.turns += turn
+			if s.turns == 0 {
+				s.following = false
+			}
+			return dir
+		}
+	}
+	return s.dir
+}
+
+// We're going in circles if we leave the same room the same way, and either
+// our turn count is the same or it's moved further away from zero. Either
+// way it will never get back to zero to let us leave the wall.
+func (s *pledge) circling() bool {
+	h := heading{s.pos, s.dir}
+	before, ok := s.seen[h]
+	s.seen[h] = s.turns
+	if !ok {
+		return false
+	}
+	if before == s.turns {
+		return true
+	}
+	return before*s.turns > 0 && abs(s.turns) > abs(before)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+func (s *pledge) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
+	defer close(cmds)
+	s.preferred = mazelib.N
+	s.dir = s.preferred
+	s.seen = make(map[heading]int)
+
+	for survey := range surveys {
+		s.dir = s.nextDir(survey)
+
+		if s.circling() {
+			s.tries++
+			if s.tries == 4 {
+				return
+			}
+			// Prefer the next direction round, keeping the count relative to it
+			s.preferred = turnRight(s.preferred)
+			s.turns--
+			s.following = s.turns != 0
+			s.seen = make(map[heading]int)
+		}
+
+		s.pos = nextCoord(s.pos, s.dir)
+		cmds <- s.dir
+	}
+}
@@ -0,0 +1,70 @@
+// Results describe how a single solver run ended, so that callers can
+// tell a solved maze apart from the different ways a solver can fail.
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/fwip/gc6/mazelib"
+)
+
+type outcome int
+
+const (
+	solved      outcome = iota // Icarus reached the treasure
+	gaveUp                     // the harness stopped the run at max-steps
+	illegalMove                // the solver walked into a wall or out of the maze
+	closedEarly                // the solver closed cmds before finding the treasure
+	timedOut                   // the solver took too long to make a decision
+	lostContact                // a move couldn't be made, e.g. daedalus went away
+)
+
+var outcomeNames = map[outcome]string{
+	solved:      "solved",
+	gaveUp:      "gave up",
+	illegalMove: "illegal move",
+	closedEarly: "closed early",
+	timedOut:    "timed out",
+	lostContact: "lost contact",
+}
+
+func (o outcome) String() string {
+	if name, ok := outcomeNames[o]; ok {
+		return name
+	}
+	return fmt.Sprintf("outcome(%d)", int(o))
+}
+
+type result struct {
+	outcome outcome
+	steps   int
+	err     error
+}
+
+func (r result) String() string {
+	if r.err != nil {
+		return fmt.Sprintf("%s after %d steps: %v", r.outcome, r.steps, r.err)
+	}
+	return fmt.Sprintf("%s after %d steps", r.outcome, r.steps)
+}
+
+// Tell the solver we're finished and wait for it to wind down.
+// Solvers exit their loop once surveys is closed, but may still be blocked
+// sending their next move, so we drain cmds until they close it.
+func stopSolver(surveys chan<- mazelib.Survey, cmds <-chan mazelib.Direction) {
+	close(surveys)
+	for range cmds {
+	}
+}
+
+// Give up on a solver that has stopped responding.
+// We can't kill its goroutine, but closing surveys and draining cmds in the
+// background means it will exit as soon as it wakes up, rather than blocking.
+func abandonSolver(surveys chan<- mazelib.Survey, cmds <-chan mazelib.Direction) {
+	close(surveys)
+	go func() {
+		for range cmds {
+		}
+	}()
+}
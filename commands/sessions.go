@@ -0,0 +1,247 @@
+// The /v2 API. Rather than one maze shared by everyone, each client starts a
+// session with a maze of its own, so several can play at once. Anything that
+// changes a maze is a POST, and errors have the status code to match, with a
+// mazelib.ErrorReply body:
+//
+//	POST   /v2/sessions             start a session, 201 with a mazelib.SessionReply
+//	POST   /v2/sessions/:id/move    make a mazelib.MoveRequest, 200 with a mazelib.Reply
+//	POST   /v2/sessions/:id/moves   make a mazelib.MovesRequest, 200 with a mazelib.MovesReply
+//	DELETE /v2/sessions/:id         end and delete a session, 204
+//	POST   /v2/done                 print the results and stop daedalus
+//
+// Moves into a wall are 409 Conflict, moves in a session that has ended are
+// 410 Gone, and sessions that never existed are 404 Not Found. A session
+// ends when Icarus finds the treasure, runs out of max-steps or time-limit,
+// or it's deleted; if he hadn't found the treasure by then it's a forfeit.
+// Sessions nobody has used for sessionIdle are deleted too, so that clients
+// that crash don't leave their mazes behind, and sessions that are over are
+// deleted once they've gone unused for sessionLinger.
+//
+// A retried POST /v2/sessions, with the same X-Request-ID, gets the session
+// the first one started, rather than leaving it to be forfeited.
+
+package commands
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/fwip/gc6/mazelib"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	sessionIdle   = 10 * time.Minute // how long a session can go unused before it's deleted
+	sessionLinger = time.Minute      // or if it's over, so retries can still be told so
+)
+
+type session struct {
+	sync.Mutex
+	id        string
+	requestID string // of the request that started it, if any
+	maze      *Maze
+	lastUsed  time.Time
+}
+
+var sessions = struct {
+	sync.Mutex
+	byID      map[string]*session
+	byRequest map[string]*session
+}{byID: make(map[string]*session), byRequest: make(map[string]*session)}
+
+var reaping sync.Once
+
+func newSessionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func addV2Routes(r *gin.Engine) {
+	reaping.Do(func() {
+		go func() {
+			for now := range time.Tick(sessionLinger / 2) {
+				reapSessions(now)
+			}
+		}()
+	})
+
+	v2 := r.Group("/v2")
+	{
+		v2.POST("/sessions", StartSession)
+		v2.POST("/sessions/:id/move", SessionMove)
+		v2.POST("/sessions/:id/moves", SessionMoves)
+		v2.DELETE("/sessions/:id", EndSession)
+		v2.POST("/done", End)
+	}
+}
+
+func abortWithError(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, mazelib.ErrorReply{Error: message})
+}
+
+// Look up the session for the request, and lock it until the request is done.
+// If there isn't one, answer with an error and return nil.
+func lockSession(c *gin.Context) *session {
+	sessions.Lock()
+	s, ok := sessions.byID[c.Param("id")]
+	sessions.Unlock()
+	if !ok {
+		abortWithError(c, http.StatusNotFound, "no such session")
+		return nil
+	}
+	s.Lock()
+	s.lastUsed = time.Now()
+	s.maze.playable() // so it's over if Icarus has run out of time
+	return s
+}
+
+// Remove a session from the list, which must be locked
+func (s *session) remove() {
+	delete(sessions.byID, s.id)
+	if s.requestID != "" {
+		delete(sessions.byRequest, s.requestID)
+	}
+}
+
+// Delete the sessions that are over and haven't been used for sessionLinger
+// before now, or haven't been used for sessionIdle, as a forfeit if they
+// weren't over. Sessions that are locked are in use, so they're left alone
+// rather than waited for.
+func reapSessions(now time.Time) {
+	sessions.Lock()
+	defer sessions.Unlock()
+	for _, s := range sessions.byID {
+		if !s.TryLock() {
+			continue
+		}
+		idle := now.Sub(s.lastUsed)
+		if (s.maze.playable() != nil && idle > sessionLinger) || idle > sessionIdle {
+			s.maze.finish(mazelib.ErrForfeited)
+			s.remove()
+		}
+		s.Unlock()
+	}
+}
+
+// Forfeit every session still being played, as daedalus is stopping
+func abandonSessions() {
+	// Handlers lock their session before the list, so don't hold the list
+	// while waiting for them
+	sessions.Lock()
+	all := make([]*session, 0, len(sessions.byID))
+	for _, s := range sessions.byID {
+		all = append(all, s)
+	}
+	sessions.Unlock()
+
+	for _, s := range all {
+		s.Lock()
+		s.maze.finish(mazelib.ErrForfeited)
+		s.Unlock()
+	}
+}
+
+// Starts a session with a new maze
+func StartSession(c *gin.Context) {
+	requestID := c.GetHeader(mazelib.RequestIDHeader)
+	if requestID != "" {
+		sessions.Lock()
+		s, ok := sessions.byRequest[requestID]
+		sessions.Unlock()
+		if ok {
+			s.Lock()
+			defer s.Unlock()
+			c.Header("Location", "/v2/sessions/"+s.id)
+			c.JSON(http.StatusCreated, mazelib.SessionReply{ID: s.id, Survey: s.maze.awoke})
+			return
+		}
+	}
+
+	m, survey, err := wake()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		abortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	s := &session{id: id, requestID: requestID, maze: m, lastUsed: time.Now()}
+	sessions.Lock()
+	sessions.byID[id] = s
+	if requestID != "" {
+		sessions.byRequest[requestID] = s
+	}
+	sessions.Unlock()
+
+	c.Header("Location", "/v2/sessions/"+id)
+	c.JSON(http.StatusCreated, mazelib.SessionReply{ID: id, Survey: survey})
+}
+
+// Makes a move. Like /move/:direction, a move with the same request ID as
+// the last one is only made once.
+func SessionMove(c *gin.Context) {
+	var req mazelib.MoveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s := lockSession(c)
+	if s == nil {
+		return
+	}
+	defer s.Unlock()
+
+	status, r := moveOnce(s.maze, c.GetHeader(mazelib.RequestIDHeader), req.Direction.String())
+	if status != http.StatusOK {
+		abortWithError(c, status, r.Message)
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+// Makes a list of moves, stopping at the first wall or the treasure, as
+// POST /moves does.
+func SessionMoves(c *gin.Context) {
+	var req mazelib.MovesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		abortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	s := lockSession(c)
+	if s == nil {
+		return
+	}
+	defer s.Unlock()
+	if err := s.maze.playable(); err != nil {
+		abortWithError(c, http.StatusGone, err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, moveAll(s.maze, req.Directions))
+}
+
+// Ends and deletes a session, as a forfeit unless it was already over
+func EndSession(c *gin.Context) {
+	s := lockSession(c)
+	if s == nil {
+		return
+	}
+	defer s.Unlock()
+
+	s.maze.finish(mazelib.ErrForfeited)
+	sessions.Lock()
+	s.remove()
+	sessions.Unlock()
+	c.Status(http.StatusNoContent)
+}
@@ -4,102 +4,247 @@ package commands
 
 import (
 	"fmt"
+	"math/rand"
+	"os"
+	"runtime"
 	"sync"
+	"time"
 
 	"github.com/fwip/gc6/mazelib"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-type mazeGen func() *Maze
-type solverGen func() solver
+type mazeGen func(*rand.Rand) *Maze
 
-var gens = []mazeGen{empty, braid, growingTree, growingTree20}
-var solvers = []solverGen{newTremaux, newNearest}
+// Makes a solver for one maze, given what's known about its size
+type solverGen func(b *bounds) explorer
+
+type namedGen struct {
+	name string
+	gen  mazeGen
+}
+
+type namedSolver struct {
+	name   string
+	solver solverGen
+}
+
+var gens = []namedGen{
+	{"empty", empty},
+	{"braid", braid},
+	{"growingTree", growingTree},
+	{"growingTree20", growingTree20},
+}
+
+var solvers = []namedSolver{
+	{"tremaux", adapted(newTremaux)},
+	{"nearest", newNearest},
+	{"leftHand", adapted(newLeftHand)},
+	{"rightHand", adapted(newRightHand)},
+	{"pledge", adapted(newPledge)},
+	{"deadEndFiller", adapted(newDeadEndFiller)},
+	{"frontier", adaptedInBounds(newFrontier)},
+	{"treasureHunter", adaptedInBounds(newTreasureHunter)},
+}
+
+// The built in solvers, plus any external ones given with --exec.
+// Every one must have a different name, so they can be told apart.
+func contestants() ([]namedSolver, error) {
+	all := append(solvers[:len(solvers):len(solvers)], execSolvers()...)
+	seen := make(map[string]bool, len(all))
+	for _, s := range all {
+		if seen[s.name] {
+			return nil, fmt.Errorf("more than one solver is named %q", s.name)
+		}
+		seen[s.name] = true
+	}
+	return all, nil
+}
 
 var shootoutCmd = &cobra.Command{
 	Use:     "shootout",
 	Aliases: []string{"bench"},
 	Short:   "Bench each solver against each maze type",
+	Long: `Runs every solver against every maze type and prints the average
+  steps taken for each pair.
+
+  Results can be saved with --save, and compared against an earlier saved
+  run with --baseline. When comparing, the baseline's seed and number of
+  runs are reused so both runs see the same mazes, and the command exits
+  non-zero if any solver got significantly worse.
+
+  Solvers written as separate programs can join in with --exec; see
+  exec_solver.go for the protocol they speak.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		shootout(gens, solvers)
+		seed := viper.GetInt64("seed")
+		times := viper.GetInt("times")
+
+		var base *shootoutRecord
+		if path := viper.GetString("baseline"); path != "" {
+			var err error
+			if base, err = loadRecord(path); err == nil {
+				err = base.compatible()
+			}
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+			seed, times = base.Seed, base.Times
+		}
+		if seed == 0 {
+			seed = time.Now().UTC().UnixNano()
+		}
+
+		solvers, err := contestants()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		results := shootout(gens, solvers, seed, times)
+		printTable(gens, solvers, results, references(gens, seed, times))
+
+		rec := newRecord(gens, solvers, seed, times, results)
+		if path := viper.GetString("save"); path != "" {
+			if err := rec.save(path); err != nil {
+				fmt.Println(err)
+				os.Exit(-1)
+			}
+		}
+
+		if base != nil {
+			regressions := compareRecords(base, rec)
+			for _, r := range regressions {
+				fmt.Println("Regression:", r)
+			}
+			if len(regressions) > 0 {
+				os.Exit(1)
+			}
+		}
 	},
 }
 
 func init() {
+	shootoutCmd.Flags().String("save", "", "Save the results as JSON to this file")
+	shootoutCmd.Flags().String("baseline", "", "Compare against results saved by an earlier --save")
+
+	viper.BindPFlag("save", shootoutCmd.Flags().Lookup("save"))
+	viper.BindPFlag("baseline", shootoutCmd.Flags().Lookup("baseline"))
+
 	RootCmd.AddCommand(shootoutCmd)
 }
 
-func shootout(gens []mazeGen, solvers []solverGen) {
-	times := viper.GetInt("times")
+func shootout(gens []namedGen, solvers []namedSolver, seed int64, times int) [][]score {
+	// Only run as many fights at once as we have CPUs.
+	// Any more and solvers can sit unscheduled long enough to hit move-timeout.
+	slots := make(chan struct{}, runtime.NumCPU())
 
 	var w sync.WaitGroup
-	results := make([][]int, len(gens))
+	results := make([][]score, len(gens))
 	for i := range gens {
-		results[i] = make([]int, len(solvers))
+		results[i] = make([]score, len(solvers))
 		for j := range solvers {
 			w.Add(1)
-			go func(times, i, j int) {
-				results[i][j] = fight(gens[i], solvers[j], times)
+			go func(i, j int) {
+				slots <- struct{}{}
+				results[i][j] = fight(gens[i].gen, solvers[j].solver, seed, times)
+				<-slots
 				w.Done()
-			}(times, i, j)
+			}(i, j)
 		}
 	}
 	w.Wait()
-	printTable(results)
+	return results
+}
+
+// score tallies the runs of one solver against one maze type.
+// Only solved runs count toward the average, failures are kept separately.
+type score struct {
+	runs     []result
+	steps    []int
+	failures map[outcome]int
+}
+
+func (s *score) add(r result) {
+	s.runs = append(s.runs, r)
+	if r.outcome == solved {
+		s.steps = append(s.steps, r.steps)
+		return
+	}
+	if s.failures == nil {
+		s.failures = make(map[outcome]int)
+	}
+	s.failures[r.outcome]++
+}
+
+func (s score) avg() int {
+	return mazelib.AvgScores(s.steps)
+}
+
+func (s score) failed() int {
+	n := 0
+	for _, count := range s.failures {
+		n += count
+	}
+	return n
 }
 
-func printTable(table [][]int) {
+// refs are shown in their own columns after the solvers
+func printTable(gens []namedGen, solvers []namedSolver, table [][]score, refs []reference) {
 	if len(table) == 0 {
 		return
 	}
 	for j := range table[0] {
-		fmt.Printf("\t%d", j)
+		fmt.Printf("\t%s", solvers[j].name)
+	}
+	fmt.Print("\tbfs\tbound\n")
+	for i := range table {
+		fmt.Print(gens[i].name)
+		for j := range table[i] {
+			if len(table[i][j].steps) == 0 {
+				fmt.Print("\t-")
+			} else {
+				fmt.Printf("\t%d", table[i][j].avg())
+			}
+		}
+		fmt.Printf("\t%d\t%d\n", refs[i].avgShortest(), refs[i].avgBound())
 	}
-	fmt.Print("\n")
+
+	// Failures are listed on their own so they don't skew the averages
 	for i := range table {
-		fmt.Print(i)
 		for j := range table[i] {
-			fmt.Printf("\t%d", table[i][j])
+			if table[i][j].failed() == 0 {
+				continue
+			}
+			fmt.Printf("%s, %s:", gens[i].name, solvers[j].name)
+			for o := gaveUp; o <= lostContact; o++ {
+				if count := table[i][j].failures[o]; count > 0 {
+					fmt.Printf(" %d %s", count, o)
+				}
+			}
+			fmt.Print("\n")
 		}
-		fmt.Print("\n")
 	}
 }
 
-func fight(gen mazeGen, solver solverGen, times int) (avgSteps int) {
-	total := 0
+// Run the solver against times mazes, the i'th of which is generated from seed+i.
+// That way every solver faces the same mazes for a given seed.
+func fight(gen mazeGen, solver solverGen, seed int64, times int) score {
+	var s score
 	for i := 0; i < times; i++ {
-		m := getSolvable(gen)
-		steps := solveIt(m, solver())
-		total += steps
+		m := getSolvable(gen, rand.New(rand.NewSource(seed+int64(i))))
+		s.add(solveIt(m, solver(newBounds(m.Width(), m.Height()))))
 	}
-	return total / times
+	return s
 }
 
-func solveIt(m *Maze, s solver) int {
-	maxSteps := viper.GetInt("max-steps")
-	surveys := make(chan mazelib.Survey, 1)
-	cmds := make(chan int)
-	defer close(surveys)
-
-	go s.Solve(surveys, cmds)
-	steps := 0
-	for m.icarus != m.end {
-		room, _ := m.GetRoom(m.Icarus())
-		surveys <- room.Walls
-		dir := <-cmds
-
-		steps++
-		err := m.moveDir(dir)
-		if err != nil {
-			fmt.Println(err)
-			return 100000000
-		}
-
-		if steps > maxSteps {
-			return steps
+// Runs a solver against a maze directly, without going through daedalus.
+func solveIt(m *Maze, e explorer) result {
+	room, _ := m.GetRoom(m.Icarus())
+	return explore(e, room.Walls, moveFunc(func(dir mazelib.Direction) (mazelib.Survey, error) {
+		if err := m.moveDir(dir); err != nil {
+			return mazelib.Survey{}, err
 		}
-	}
-
-	return steps
+		return m.LookAround()
+	}))
 }
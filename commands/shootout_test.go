@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSolversSolve(t *testing.T) {
+	setSize(15, 10)
+	for _, g := range gens {
+		for _, s := range solvers {
+			for seed := int64(1); seed <= 10; seed++ {
+				m := getSolvable(g.gen, rand.New(rand.NewSource(seed)))
+				r := solveIt(m, s.solver(newBounds(m.Width(), m.Height())))
+				if r.outcome == illegalMove || r.outcome == timedOut {
+					t.Errorf("%s on %s (seed %d): %v", s.name, g.name, seed, r)
+				}
+			}
+		}
+	}
+}
+
+// As when solving mazes from a daedalus elsewhere
+func TestSolversSolveMazesOfUnknownSize(t *testing.T) {
+	setSize(15, 10)
+	for _, g := range gens {
+		for _, s := range solvers {
+			for seed := int64(1); seed <= 3; seed++ {
+				m := getSolvable(g.gen, rand.New(rand.NewSource(seed)))
+				r := solveIt(m, s.solver(newBounds(0, 0)))
+				if r.outcome == illegalMove || r.outcome == timedOut {
+					t.Errorf("%s on %s (seed %d): %v", s.name, g.name, seed, r)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkSolvers(b *testing.B) {
+	for _, g := range gens {
+		for _, s := range solvers {
+			b.Run(g.name+"/"+s.name, func(b *testing.B) {
+				setSize(15, 10)
+				b.ReportAllocs()
+				steps := 0
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					m := getSolvable(g.gen, rand.New(rand.NewSource(int64(i))))
+					b.StartTimer()
+					steps += solveIt(m, s.solver(newBounds(m.Width(), m.Height()))).steps
+				}
+				b.ReportMetric(float64(steps)/float64(b.N), "steps/op")
+			})
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+
+	"github.com/fwip/gc6/mazelib"
+)
+
+func TestSolversGiveUpWithNowhereToGo(t *testing.T) {
+	boxedIn := observation{Survey: mazelib.Survey{Top: true, Right: true, Bottom: true, Left: true}}
+	for name, e := range map[string]explorer{
+		"frontier":      adapt(newFrontier(newBounds(0, 0))),
+		"deadEndFiller": adapt(newDeadEndFiller()),
+	} {
+		if _, err := e.Next(context.Background(), boxedIn); err != errGaveUp {
+			t.Errorf("expected %s to give up in a room with no way out, got %v", name, err)
+		}
+		e.Done(boxedIn)
+	}
+}
+
+// Put walls on the given sides of the room at c, and the rooms beside them
+func wallOff(m *Maze, c mazelib.Coordinate, dirs ...mazelib.Direction) {
+	for _, dir := range dirs {
+		n := nextCoord(c, dir)
+		m.rooms[c.Y][c.X].AddWall(dir)
+		m.rooms[n.Y][n.X].AddWall(dir.Reverse())
+	}
+}
+
+// A corridor three rooms long, with Icarus in the middle and the treasure
+// to his right
+func middleOfCorridor() *Maze {
+	setSize(3, 1)
+	m := empty(rand.New(rand.NewSource(1)))
+	m.SetStartPoint(1, 0)
+	m.SetTreasure(2, 0)
+	return m
+}
+
+// A loop of rooms around a walled off island in a 4x3 maze, with a
+// corridor leading off its top right corner down to the treasure.
+// Icarus wakes up above the island.
+func island() *Maze {
+	setSize(4, 3)
+	m := empty(rand.New(rand.NewSource(1)))
+	wallOff(m, mazelib.Coordinate{X: 1, Y: 1}, mazelib.N, mazelib.S, mazelib.E, mazelib.W)
+	wallOff(m, mazelib.Coordinate{X: 2, Y: 1}, mazelib.E)
+	wallOff(m, mazelib.Coordinate{X: 2, Y: 2}, mazelib.E)
+	m.SetStartPoint(1, 0)
+	m.SetTreasure(3, 2)
+	return m
+}
+
+func TestWallFollowers(t *testing.T) {
+	tests := []struct {
+		name  string
+		gen   func() solver
+		steps int
+	}{
+		// Both walk up into the wall, then turn towards their hand
+		{"leftHand", newLeftHand, 3},
+		{"rightHand", newRightHand, 1},
+	}
+	for _, test := range tests {
+		if r := solveIt(middleOfCorridor(), adapt(test.gen())); r.outcome != solved || r.steps != test.steps {
+			t.Errorf("expected %s to solve the corridor in %d steps, got %v", test.name, test.steps, r)
+		}
+
+		// With a hand on the island they'd go round it forever
+		if r := solveIt(island(), adapt(test.gen())); r.outcome != closedEarly {
+			t.Errorf("expected %s to give up circling the island, got %v", test.name, r)
+		}
+	}
+}
+
+func TestPledge(t *testing.T) {
+	if r := solveIt(middleOfCorridor(), adapt(newPledge())); r.outcome != solved {
+		t.Errorf("expected pledge to solve the corridor, got %v", r)
+	}
+
+	// Counting its turns, it lets go of the island where wall followers can't
+	if r := solveIt(island(), adapt(newPledge())); r.outcome != solved {
+		t.Errorf("expected pledge to get away from the island, got %v", r)
+	}
+}
@@ -0,0 +1,190 @@
+// Tournament ranks solvers head to head instead of by average steps.
+// Every solver runs on the same mazes, and on each maze every pair of solvers
+// plays a game: whoever took fewer steps wins, and a solved maze beats an
+// unsolved one. The games are then turned into Bradley-Terry ratings.
+
+package commands
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var tournamentCmd = &cobra.Command{
+	Use:     "tournament",
+	Aliases: []string{"rank"},
+	Short:   "Rank solvers by playing them against each other",
+	Long: `Runs every solver on the same mazes from each maze type, scores each
+  pair of solvers on each maze as a win, loss or draw, and prints a rating
+  table overall and for each maze type.
+
+  Ratings are on an Elo-like scale, where a solver rated 400 points higher
+  is expected to win ten times as often.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		seed := viper.GetInt64("seed")
+		if seed == 0 {
+			seed = time.Now().UTC().UnixNano()
+		}
+
+		solvers, err := contestants()
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(-1)
+		}
+		results := shootout(gens, solvers, seed, viper.GetInt("times"))
+
+		overall := newStandings(len(solvers))
+		for i := range gens {
+			s := newStandings(len(solvers))
+			s.play(results[i])
+			overall.play(results[i])
+			fmt.Println(gens[i].name)
+			s.print(solvers)
+			fmt.Print("\n")
+		}
+		fmt.Println("overall")
+		overall.print(solvers)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(tournamentCmd)
+}
+
+// standings holds the games played between each pair of solvers.
+// wins[i][j] counts the times i beat j, with a draw counting half to each.
+type standings struct {
+	wins             [][]float64
+	won, lost, drawn []int
+}
+
+func newStandings(n int) *standings {
+	s := &standings{
+		wins:  make([][]float64, n),
+		won:   make([]int, n),
+		lost:  make([]int, n),
+		drawn: make([]int, n),
+	}
+	for i := range s.wins {
+		s.wins[i] = make([]float64, n)
+	}
+	return s
+}
+
+// Play every pair of solvers against each other on every maze.
+// scores holds one score per solver, whose runs line up maze by maze.
+func (s *standings) play(scores []score) {
+	for i := range scores {
+		for j := i + 1; j < len(scores); j++ {
+			for k := range scores[i].runs {
+				switch beats(scores[i].runs[k], scores[j].runs[k]) {
+				case 1:
+					s.wins[i][j]++
+					s.won[i]++
+					s.lost[j]++
+				case -1:
+					s.wins[j][i]++
+					s.won[j]++
+					s.lost[i]++
+				default:
+					s.wins[i][j] += 0.5
+					s.wins[j][i] += 0.5
+					s.drawn[i]++
+					s.drawn[j]++
+				}
+			}
+		}
+	}
+}
+
+// Returns 1 if a beat b, -1 if b beat a, and 0 for a draw.
+func beats(a, b result) int {
+	aSolved, bSolved := a.outcome == solved, b.outcome == solved
+	switch {
+	case aSolved && !bSolved:
+		return 1
+	case bSolved && !aSolved:
+		return -1
+	case !aSolved || a.steps == b.steps:
+		return 0
+	case a.steps < b.steps:
+		return 1
+	}
+	return -1
+}
+
+// Fit Bradley-Terry strengths with the usual minorize-maximize iteration,
+// and put them on an Elo-like scale centered on 1500.
+// Every pair is given one extra drawn game, so that a solver that never
+// loses still ends up with a finite rating.
+func (s *standings) ratings() []float64 {
+	n := len(s.wins)
+	strength := make([]float64, n)
+	for i := range strength {
+		strength[i] = 1
+	}
+
+	for iter := 0; iter < 1000; iter++ {
+		next := make([]float64, n)
+		change := 0.0
+		for i := 0; i < n; i++ {
+			won, denom := 0.0, 0.0
+			for j := 0; j < n; j++ {
+				if i == j {
+					continue
+				}
+				won += s.wins[i][j] + 0.5
+				games := s.wins[i][j] + s.wins[j][i] + 1
+				denom += games / (strength[i] + strength[j])
+			}
+			if denom == 0 {
+				next[i] = 1
+				continue
+			}
+			next[i] = won / denom
+		}
+
+		// Normalize so the geometric mean strength is 1
+		logSum := 0.0
+		for _, p := range next {
+			logSum += math.Log(p)
+		}
+		scale := math.Exp(logSum / float64(n))
+		for i := range next {
+			next[i] /= scale
+			change = math.Max(change, math.Abs(next[i]-strength[i]))
+		}
+		strength = next
+		if change < 1e-9 {
+			break
+		}
+	}
+
+	ratings := make([]float64, n)
+	for i, p := range strength {
+		ratings[i] = 1500 + 400*math.Log10(p)
+	}
+	return ratings
+}
+
+func (s *standings) print(solvers []namedSolver) {
+	ratings := s.ratings()
+	order := make([]int, len(ratings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return ratings[order[a]] > ratings[order[b]]
+	})
+
+	fmt.Println("solver\trating\twon\tlost\tdrawn")
+	for _, i := range order {
+		fmt.Printf("%s\t%.0f\t%d\t%d\t%d\n", solvers[i].name, ratings[i], s.won[i], s.lost[i], s.drawn[i])
+	}
+}
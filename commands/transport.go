@@ -0,0 +1,137 @@
+// A transport is how Icarus gets to a maze. Over http he talks to a daedalus
+// server with a mazelib.Client, wherever it is, and stream does the same but
+// makes his moves over a websocket. v2 uses the /v2 HTTP API, with a
+// session for each maze. grpc uses daedalus's gRPC API instead
+// of the HTTP one. inproc skips the server and walks the
+// maze directly, which is far faster and needs no port.
+
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fwip/gc6/mazelib"
+)
+
+type transport interface {
+	// Awake starts a new maze, returning what Icarus sees when he wakes up.
+	Awake() (mazelib.Survey, error)
+	// Move moves Icarus one room, returning what he sees there, or
+	// mazelib.ErrVictory once he finds the treasure.
+	Move(dir mazelib.Direction) (mazelib.Survey, error)
+	// Done says Icarus has finished solving mazes.
+	Done() error
+}
+
+// Transports that can wait for daedalus to be ready
+type waiter interface {
+	WaitReady(timeout time.Duration) error
+}
+
+// Solves mazes in this process, the same way daedalus would serve them
+type inproc struct{}
+
+func (inproc) Awake() (mazelib.Survey, error) {
+	return wakeIcarus("")
+}
+
+func (inproc) Move(dir mazelib.Direction) (mazelib.Survey, error) {
+	m, unlock := lockCurrentMaze()
+	defer unlock()
+	return moveIcarus(m, dir)
+}
+
+func (inproc) Done() error {
+	printResults()
+	return nil
+}
+
+// Makes moves over a websocket, opened on the first Awake
+type streaming struct {
+	client *mazelib.Client
+	stream *mazelib.Stream
+}
+
+func (s *streaming) WaitReady(timeout time.Duration) error {
+	return s.client.WaitReady(timeout)
+}
+
+func (s *streaming) Awake() (mazelib.Survey, error) {
+	survey, err := s.client.Awake()
+	if err == nil && s.stream == nil {
+		s.stream, err = s.client.Stream()
+	}
+	return survey, err
+}
+
+func (s *streaming) Move(dir mazelib.Direction) (mazelib.Survey, error) {
+	return s.stream.Move(dir)
+}
+
+func (s *streaming) Done() error {
+	if s.stream != nil {
+		s.stream.Close()
+	}
+	return s.client.Done()
+}
+
+// Solves each maze in a /v2 session of its own
+type sessionTransport struct {
+	client  *mazelib.Client
+	session *mazelib.Session
+}
+
+func (s *sessionTransport) WaitReady(timeout time.Duration) error {
+	return s.client.WaitReady(timeout)
+}
+
+func (s *sessionTransport) Awake() (mazelib.Survey, error) {
+	if s.session != nil {
+		if err := s.session.End(); err != nil {
+			return mazelib.Survey{}, err
+		}
+	}
+	session, survey, err := s.client.Start()
+	if err == nil {
+		s.session = session
+	}
+	return survey, err
+}
+
+func (s *sessionTransport) Move(dir mazelib.Direction) (mazelib.Survey, error) {
+	return s.session.Move(dir)
+}
+
+func (s *sessionTransport) Moves(dirs []mazelib.Direction) ([]mazelib.Survey, error) {
+	return s.session.Moves(dirs)
+}
+
+func (s *sessionTransport) Done() error {
+	if s.session != nil {
+		if err := s.session.End(); err != nil {
+			return err
+		}
+	}
+	return s.client.Shutdown()
+}
+
+// The transport called name, or fallback if name is empty
+func pickTransport(name, fallback string) (transport, error) {
+	if name == "" {
+		name = fallback
+	}
+	switch name {
+	case "inproc":
+		return inproc{}, nil
+	case "http":
+		return newClient(), nil
+	case "stream":
+		return &streaming{client: newClient()}, nil
+	case "v2":
+		return &sessionTransport{client: newClient()}, nil
+	case "grpc":
+		return newGRPCTransport()
+	}
+	return nil, fmt.Errorf("no transport named %q, expected inproc, http, stream, v2 or grpc", name)
+}
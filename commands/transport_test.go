@@ -0,0 +1,197 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fwip/gc6/daedaluspb"
+	"github.com/fwip/gc6/mazelib"
+	"github.com/spf13/viper"
+	"google.golang.org/grpc"
+)
+
+func TestInprocTransport(t *testing.T) {
+	setSize(5, 5)
+	viper.Set("times", 2)
+	defer viper.Set("times", 1)
+
+	scores = nil
+	if err := RunIcarus(inproc{}); err != nil {
+		t.Error(err)
+	}
+	if len(scores) != 2 {
+		t.Errorf("expected 2 mazes to be solved, got %d", len(scores))
+	}
+}
+
+// Done would stop the server, and the test with it, so these only solve
+func TestServerTransports(t *testing.T) {
+	setSize(5, 5)
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serveGRPC(ln)
+	rpc, err := dialGRPC(ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rpc.WaitReady(time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tr := range map[string]transport{
+		"http":   mazelib.NewClient(server.URL),
+		"stream": &streaming{client: mazelib.NewClient(server.URL)},
+		"v2":     &sessionTransport{client: mazelib.NewClient(server.URL)},
+		"grpc":   rpc,
+	} {
+		scores = nil
+		if err := solveMaze(tr, newNearest); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		if len(scores) != 1 {
+			t.Errorf("%s: expected the maze to be solved, got %d scores", name, len(scores))
+		}
+
+		m := empty(rand.New(rand.NewSource(1)))
+		m.SetStartPoint(0, 0)
+		m.SetTreasure(4, 4)
+		if s, ok := tr.(*sessionTransport); ok {
+			if _, err := s.Awake(); err != nil {
+				t.Fatal(err)
+			}
+			sessions.byID[s.session.ID].maze = m
+		} else {
+			currentMaze = m
+		}
+		if _, err := tr.Move(mazelib.N); err != mazelib.ErrWall {
+			t.Errorf("%s: expected ErrWall, got %v", name, err)
+		}
+	}
+}
+
+func TestStreamBeforeAwake(t *testing.T) {
+	currentMaze = nil
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	s, err := mazelib.NewClient(server.URL).Stream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	_, err = s.Move(mazelib.E)
+	var statusErr *mazelib.StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected a move before waking up to be 400 Bad Request, got %v", err)
+	}
+}
+
+// Answers Awake, but never answers a move
+type hungDaedalus struct {
+	daedaluspb.UnimplementedDaedalusServer
+}
+
+func (hungDaedalus) Awake(ctx context.Context, req *daedaluspb.AwakeRequest) (*daedaluspb.MoveReply, error) {
+	return &daedaluspb.MoveReply{Survey: &daedaluspb.Survey{}}, nil
+}
+
+func (hungDaedalus) Explore(stream daedaluspb.Daedalus_ExploreServer) error {
+	<-stream.Context().Done()
+	return stream.Context().Err()
+}
+
+func TestGRPCMovesTimeOut(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	daedaluspb.RegisterDaedalusServer(server, hungDaedalus{})
+	go server.Serve(ln)
+	defer server.Stop()
+
+	rpc, err := dialGRPC(ln.Addr().String(), 50*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rpc.conn.Close()
+	if _, err := rpc.Awake(); err != nil {
+		t.Fatal(err)
+	}
+
+	moved := make(chan error)
+	go func() {
+		_, err := rpc.Move(mazelib.E)
+		moved <- err
+	}()
+	select {
+	case err := <-moved:
+		if err == nil {
+			t.Error("expected a move daedalus never answers to fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("expected a move daedalus never answers to time out")
+	}
+}
+
+// Wakes Icarus up in mazes whose time has already run out, the first n times
+type expiringTransport struct {
+	*grpcTransport
+	n int
+}
+
+func (e *expiringTransport) Awake() (mazelib.Survey, error) {
+	survey, err := e.grpcTransport.Awake()
+	if e.n > 0 {
+		e.n--
+		m, unlock := lockCurrentMaze()
+		m.deadline = time.Now().Add(-time.Second)
+		unlock()
+	}
+	return survey, err
+}
+
+func TestGRPCCarriesOnAfterAMazeIsOver(t *testing.T) {
+	isolateDaedalus(t, 5, 5)
+	oldTimes, oldSolver := viper.Get("times"), viper.Get("solver")
+	t.Cleanup(func() {
+		viper.Set("times", oldTimes)
+		viper.Set("solver", oldSolver)
+		stopping = make(chan struct{})
+		stopOnce = sync.Once{}
+	})
+	viper.Set("times", 2)
+	viper.Set("solver", "nearest")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serveGRPC(ln)
+	rpc, err := dialGRPC(ln.Addr().String(), time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// daedalus ends the stream when the first maze runs out of time,
+	// and the second has to be solved over a new one
+	err = RunIcarus(&expiringTransport{grpcTransport: rpc, n: 1})
+	if err == nil {
+		t.Error("expected the maze that ran out of time to fail")
+	}
+	if len(scores) != 1 || forfeits != 1 {
+		t.Errorf("expected 1 maze to be solved and 1 forfeited, got %d and %d", len(scores), forfeits)
+	}
+}
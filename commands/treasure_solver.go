@@ -0,0 +1,118 @@
+// The treasure is equally likely to be in any room we haven't explored yet,
+// so rather than just heading for the nearest unexplored room, this picks
+// whichever is expected to find the treasure soonest.
+//
+// Each unexplored room on the frontier is treated as the way into a region of
+// the maze: the unexplored rooms that are closer to it than to any other
+// frontier room. Rooms near the edge of what we know might turn out to be
+// outside the maze, so each counts by how likely it is to be inside. Searching
+// a region costs the walk to its frontier plus about a step per room, and
+// finds the treasure with probability in proportion to its size. Searching in
+// order of probability over cost is the classic way to minimize the expected
+// time to find something, so that's what we head for.
+package commands
+
+import "github.com/fwip/gc6/mazelib"
+
+type treasureHunter struct {
+	memory plot
+	bounds *bounds
+	pos    mazelib.Coordinate
+	path   path
+}
+
+func newTreasureHunter(b *bounds) solver {
+	return &treasureHunter{bounds: b}
+}
+
+// Share out the unexplored rooms between the frontier rooms,
+// each taking the ones closest to it, and count how many each gets.
+// We don't know where the walls are yet, so assume there aren't any.
+// If we don't know how big the maze is either, only count rooms up to one
+// past the ones we know about, or there'd be no end to them.
+func (s *treasureHunter) regions(frontier []mazelib.Coordinate) []float64 {
+	min, max := s.bounds.Extents()
+	min = mazelib.Coordinate{X: min.X - 1, Y: min.Y - 1}
+	max = mazelib.Coordinate{X: max.X + 1, Y: max.Y + 1}
+
+	sizes := make([]float64, len(frontier))
+	owner := make(map[mazelib.Coordinate]int, len(frontier))
+	queue := make([]mazelib.Coordinate, 0, len(frontier))
+	for i, c := range frontier {
+		owner[c] = i
+		queue = append(queue, c)
+	}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		sizes[owner[c]] += s.bounds.Likelihood(c)
+
+		for _, offset := range offsets {
+			n := mazelib.Coordinate{X: c.X + offset.X, Y: c.Y + offset.Y}
+			if _, claimed := owner[n]; claimed {
+				continue
+			}
+			if _, explored := s.memory[n]; explored || !s.bounds.Contains(n) {
+				continue
+			}
+			if n.X < min.X || n.Y < min.Y || n.X > max.X || n.Y > max.Y {
+				continue
+			}
+			owner[n] = owner[c]
+			queue = append(queue, n)
+		}
+	}
+	return sizes
+}
+
+// Returns false once there's nowhere left to look
+func (s *treasureHunter) nextDir() (mazelib.Direction, bool) {
+	if len(s.path) == 0 {
+		rooms, dist, directions := s.memory.Frontier(s.pos)
+
+		var frontier []mazelib.Coordinate
+		for _, c := range rooms {
+			if s.bounds.Contains(c) {
+				frontier = append(frontier, c)
+			}
+		}
+		if len(frontier) == 0 {
+			return 0, false
+		}
+
+		// Probability over cost, leaving out the chance of the treasure
+		// being anywhere at all, since it's the same for every region
+		sizes := s.regions(frontier)
+		best, bestIndex := 0, -1.0
+		for i, c := range frontier {
+			size := sizes[i]
+			if index := size / (float64(dist[c]) + size); index > bestIndex {
+				best, bestIndex = i, index
+			}
+		}
+		s.path = pathTo(directions, s.pos, frontier[best])
+	}
+	next := s.path[0]
+	s.path = s.path[1:]
+	return next, true
+}
+
+func (s *treasureHunter) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
+	defer close(cmds)
+
+	s.memory = make(plot)
+
+	for survey := range surveys {
+		s.memory.Record(s.pos, survey)
+		s.bounds.Record(s.pos, survey)
+
+		newdir, ok := s.nextDir()
+		if !ok {
+			return
+		}
+
+		s.pos = nextCoord(s.pos, newdir)
+		cmds <- newdir
+	}
+}
@@ -6,7 +6,7 @@ type tremaux struct {
 	memory       map[mazelib.Coordinate]mazelib.Survey
 	visited      map[mazelib.Coordinate]int
 	pos          mazelib.Coordinate
-	dir          int
+	dir          mazelib.Direction
 	backtracking bool
 }
 
@@ -14,7 +14,7 @@ func newTremaux() solver {
 	return &tremaux{}
 }
 
-func (s *tremaux) nextDir(survey mazelib.Survey) int {
+func (s *tremaux) nextDir(survey mazelib.Survey) mazelib.Direction {
 	valid := validDirections(survey)
 	minDir := valid[0]
 	minCost := s.visited[nextCoord(s.pos, minDir)]
@@ -30,7 +30,7 @@ func (s *tremaux) nextDir(survey mazelib.Survey) int {
 	return minDir
 }
 
-func (s *tremaux) Solve(surveys <-chan mazelib.Survey, cmds chan<- int) {
+func (s *tremaux) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
 	defer close(cmds)
 	s.dir = mazelib.N
 
@@ -44,11 +44,11 @@ func (s *tremaux) Solve(surveys <-chan mazelib.Survey, cmds chan<- int) {
 		newdir := s.nextDir(survey)
 		ahead := nextCoord(s.pos, newdir)
 		if !s.backtracking && s.visited[ahead] > 0 {
-			newdir = int(direction(s.dir).Reverse())
+			newdir = s.dir.Reverse()
 			s.backtracking = true
 		}
 
-		if int(direction(newdir).Reverse()) == s.dir {
+		if newdir.Reverse() == s.dir {
 			s.visited[s.pos]++
 		}
 
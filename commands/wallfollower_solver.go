@@ -0,0 +1,69 @@
+// Wall followers keep one hand on the wall and walk until they find the treasure.
+// That works in any maze without loops, but in a braid maze they can end up
+// circling an island forever, so they give up once they notice they're
+// repeating themselves.
+package commands
+
+import "github.com/fwip/gc6/mazelib"
+
+type heading struct {
+	pos mazelib.Coordinate
+	dir mazelib.Direction
+}
+
+type wallFollower struct {
+	pos    mazelib.Coordinate
+	dir    mazelib.Direction
+	turn   func(mazelib.Direction) mazelib.Direction // towards the hand on the wall
+	onWall bool
+	seen   map[heading]bool
+}
+
+func newLeftHand() solver {
+	return &wallFollower{turn: turnLeft}
+}
+
+func newRightHand() solver {
+	return &wallFollower{turn: turnRight}
+}
+
+// Walk straight until we find a wall to put our hand on.
+// After that, prefer turning towards the wall, then going straight, then
+// turning away, and only turn back at a dead end.
+func (s *wallFollower) nextDir(survey mazelib.Survey) mazelib.Direction {
+	towards := s.turn(s.dir)
+	if !s.onWall && canGo(survey, towards) && canGo(survey, s.dir) {
+		return s.dir
+	}
+	s.onWall = true
+
+	for _, dir := range []mazelib.Direction{towards, s.dir, towards.Reverse()} {
+		if canGo(survey, dir) {
+			return dir
+		}
+	}
+	return s.dir.Reverse()
+}
+
+func (s *wallFollower) Solve(surveys <-chan mazelib.Survey, cmds chan<- mazelib.Direction) {
+	defer close(cmds)
+	s.dir = mazelib.N
+	s.seen = make(map[heading]bool)
+
+	for survey := range surveys {
+		s.dir = s.nextDir(survey)
+
+		// Once we're following a wall, leaving the same room the same way
+		// twice means we're going in circles
+		if s.onWall {
+			h := heading{s.pos, s.dir}
+			if s.seen[h] {
+				return
+			}
+			s.seen[h] = true
+		}
+
+		s.pos = nextCoord(s.pos, s.dir)
+		cmds <- s.dir
+	}
+}
@@ -0,0 +1,480 @@
+// The Daedalus service lets Icarus solve laybrinths over gRPC.
+// It serves the same mazes as the HTTP API, from the same server.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: daedalus.proto
+
+package daedaluspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The numbers match mazelib.Direction.
+type Direction int32
+
+const (
+	Direction_DIRECTION_UNSPECIFIED Direction = 0
+	Direction_DIRECTION_UP          Direction = 1
+	Direction_DIRECTION_DOWN        Direction = 2
+	Direction_DIRECTION_RIGHT       Direction = 3
+	Direction_DIRECTION_LEFT        Direction = 4
+)
+
+// Enum value maps for Direction.
+var (
+	Direction_name = map[int32]string{
+		0: "DIRECTION_UNSPECIFIED",
+		1: "DIRECTION_UP",
+		2: "DIRECTION_DOWN",
+		3: "DIRECTION_RIGHT",
+		4: "DIRECTION_LEFT",
+	}
+	Direction_value = map[string]int32{
+		"DIRECTION_UNSPECIFIED": 0,
+		"DIRECTION_UP":          1,
+		"DIRECTION_DOWN":        2,
+		"DIRECTION_RIGHT":       3,
+		"DIRECTION_LEFT":        4,
+	}
+)
+
+func (x Direction) Enum() *Direction {
+	p := new(Direction)
+	*p = x
+	return p
+}
+
+func (x Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_daedalus_proto_enumTypes[0].Descriptor()
+}
+
+func (Direction) Type() protoreflect.EnumType {
+	return &file_daedalus_proto_enumTypes[0]
+}
+
+func (x Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Direction.Descriptor instead.
+func (Direction) EnumDescriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{0}
+}
+
+// True where there's a wall.
+type Survey struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Top           bool                   `protobuf:"varint,1,opt,name=top,proto3" json:"top,omitempty"`
+	Right         bool                   `protobuf:"varint,2,opt,name=right,proto3" json:"right,omitempty"`
+	Bottom        bool                   `protobuf:"varint,3,opt,name=bottom,proto3" json:"bottom,omitempty"`
+	Left          bool                   `protobuf:"varint,4,opt,name=left,proto3" json:"left,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Survey) Reset() {
+	*x = Survey{}
+	mi := &file_daedalus_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Survey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Survey) ProtoMessage() {}
+
+func (x *Survey) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Survey.ProtoReflect.Descriptor instead.
+func (*Survey) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Survey) GetTop() bool {
+	if x != nil {
+		return x.Top
+	}
+	return false
+}
+
+func (x *Survey) GetRight() bool {
+	if x != nil {
+		return x.Right
+	}
+	return false
+}
+
+func (x *Survey) GetBottom() bool {
+	if x != nil {
+		return x.Bottom
+	}
+	return false
+}
+
+func (x *Survey) GetLeft() bool {
+	if x != nil {
+		return x.Left
+	}
+	return false
+}
+
+type AwakeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AwakeRequest) Reset() {
+	*x = AwakeRequest{}
+	mi := &file_daedalus_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AwakeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AwakeRequest) ProtoMessage() {}
+
+func (x *AwakeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AwakeRequest.ProtoReflect.Descriptor instead.
+func (*AwakeRequest) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{1}
+}
+
+type MoveRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Direction Direction              `protobuf:"varint,1,opt,name=direction,proto3,enum=daedalus.Direction" json:"direction,omitempty"`
+	// If set, a move with the same ID as the last one isn't made again,
+	// so it's safe to retry.
+	RequestId     string `protobuf:"bytes,2,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveRequest) Reset() {
+	*x = MoveRequest{}
+	mi := &file_daedalus_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveRequest) ProtoMessage() {}
+
+func (x *MoveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveRequest.ProtoReflect.Descriptor instead.
+func (*MoveRequest) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MoveRequest) GetDirection() Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return Direction_DIRECTION_UNSPECIFIED
+}
+
+func (x *MoveRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type MoveReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Survey        *Survey                `protobuf:"bytes,1,opt,name=survey,proto3" json:"survey,omitempty"`
+	Victory       bool                   `protobuf:"varint,2,opt,name=victory,proto3" json:"victory,omitempty"`
+	Wall          bool                   `protobuf:"varint,3,opt,name=wall,proto3" json:"wall,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MoveReply) Reset() {
+	*x = MoveReply{}
+	mi := &file_daedalus_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MoveReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MoveReply) ProtoMessage() {}
+
+func (x *MoveReply) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MoveReply.ProtoReflect.Descriptor instead.
+func (*MoveReply) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *MoveReply) GetSurvey() *Survey {
+	if x != nil {
+		return x.Survey
+	}
+	return nil
+}
+
+func (x *MoveReply) GetVictory() bool {
+	if x != nil {
+		return x.Victory
+	}
+	return false
+}
+
+func (x *MoveReply) GetWall() bool {
+	if x != nil {
+		return x.Wall
+	}
+	return false
+}
+
+func (x *MoveReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type DoneRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DoneRequest) Reset() {
+	*x = DoneRequest{}
+	mi := &file_daedalus_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DoneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DoneRequest) ProtoMessage() {}
+
+func (x *DoneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DoneRequest.ProtoReflect.Descriptor instead.
+func (*DoneRequest) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{4}
+}
+
+type DoneReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DoneReply) Reset() {
+	*x = DoneReply{}
+	mi := &file_daedalus_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DoneReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DoneReply) ProtoMessage() {}
+
+func (x *DoneReply) ProtoReflect() protoreflect.Message {
+	mi := &file_daedalus_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DoneReply.ProtoReflect.Descriptor instead.
+func (*DoneReply) Descriptor() ([]byte, []int) {
+	return file_daedalus_proto_rawDescGZIP(), []int{5}
+}
+
+var File_daedalus_proto protoreflect.FileDescriptor
+
+const file_daedalus_proto_rawDesc = "" +
+	"\n" +
+	"\x0edaedalus.proto\x12\bdaedalus\"\\\n" +
+	"\x06Survey\x12\x10\n" +
+	"\x03top\x18\x01 \x01(\bR\x03top\x12\x14\n" +
+	"\x05right\x18\x02 \x01(\bR\x05right\x12\x16\n" +
+	"\x06bottom\x18\x03 \x01(\bR\x06bottom\x12\x12\n" +
+	"\x04left\x18\x04 \x01(\bR\x04left\"\x0e\n" +
+	"\fAwakeRequest\"_\n" +
+	"\vMoveRequest\x121\n" +
+	"\tdirection\x18\x01 \x01(\x0e2\x13.daedalus.DirectionR\tdirection\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x02 \x01(\tR\trequestId\"}\n" +
+	"\tMoveReply\x12(\n" +
+	"\x06survey\x18\x01 \x01(\v2\x10.daedalus.SurveyR\x06survey\x12\x18\n" +
+	"\avictory\x18\x02 \x01(\bR\avictory\x12\x12\n" +
+	"\x04wall\x18\x03 \x01(\bR\x04wall\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"\r\n" +
+	"\vDoneRequest\"\v\n" +
+	"\tDoneReply*u\n" +
+	"\tDirection\x12\x19\n" +
+	"\x15DIRECTION_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fDIRECTION_UP\x10\x01\x12\x12\n" +
+	"\x0eDIRECTION_DOWN\x10\x02\x12\x13\n" +
+	"\x0fDIRECTION_RIGHT\x10\x03\x12\x12\n" +
+	"\x0eDIRECTION_LEFT\x10\x042\xe3\x01\n" +
+	"\bDaedalus\x124\n" +
+	"\x05Awake\x12\x16.daedalus.AwakeRequest\x1a\x13.daedalus.MoveReply\x122\n" +
+	"\x04Move\x12\x15.daedalus.MoveRequest\x1a\x13.daedalus.MoveReply\x122\n" +
+	"\x04Done\x12\x15.daedalus.DoneRequest\x1a\x13.daedalus.DoneReply\x129\n" +
+	"\aExplore\x12\x15.daedalus.MoveRequest\x1a\x13.daedalus.MoveReply(\x010\x01B Z\x1egithub.com/fwip/gc6/daedaluspbb\x06proto3"
+
+var (
+	file_daedalus_proto_rawDescOnce sync.Once
+	file_daedalus_proto_rawDescData []byte
+)
+
+func file_daedalus_proto_rawDescGZIP() []byte {
+	file_daedalus_proto_rawDescOnce.Do(func() {
+		file_daedalus_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_daedalus_proto_rawDesc), len(file_daedalus_proto_rawDesc)))
+	})
+	return file_daedalus_proto_rawDescData
+}
+
+var file_daedalus_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_daedalus_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_daedalus_proto_goTypes = []any{
+	(Direction)(0),       // 0: daedalus.Direction
+	(*Survey)(nil),       // 1: daedalus.Survey
+	(*AwakeRequest)(nil), // 2: daedalus.AwakeRequest
+	(*MoveRequest)(nil),  // 3: daedalus.MoveRequest
+	(*MoveReply)(nil),    // 4: daedalus.MoveReply
+	(*DoneRequest)(nil),  // 5: daedalus.DoneRequest
+	(*DoneReply)(nil),    // 6: daedalus.DoneReply
+}
+var file_daedalus_proto_depIdxs = []int32{
+	0, // 0: daedalus.MoveRequest.direction:type_name -> daedalus.Direction
+	1, // 1: daedalus.MoveReply.survey:type_name -> daedalus.Survey
+	2, // 2: daedalus.Daedalus.Awake:input_type -> daedalus.AwakeRequest
+	3, // 3: daedalus.Daedalus.Move:input_type -> daedalus.MoveRequest
+	5, // 4: daedalus.Daedalus.Done:input_type -> daedalus.DoneRequest
+	3, // 5: daedalus.Daedalus.Explore:input_type -> daedalus.MoveRequest
+	4, // 6: daedalus.Daedalus.Awake:output_type -> daedalus.MoveReply
+	4, // 7: daedalus.Daedalus.Move:output_type -> daedalus.MoveReply
+	6, // 8: daedalus.Daedalus.Done:output_type -> daedalus.DoneReply
+	4, // 9: daedalus.Daedalus.Explore:output_type -> daedalus.MoveReply
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_daedalus_proto_init() }
+func file_daedalus_proto_init() {
+	if File_daedalus_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_daedalus_proto_rawDesc), len(file_daedalus_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_daedalus_proto_goTypes,
+		DependencyIndexes: file_daedalus_proto_depIdxs,
+		EnumInfos:         file_daedalus_proto_enumTypes,
+		MessageInfos:      file_daedalus_proto_msgTypes,
+	}.Build()
+	File_daedalus_proto = out.File
+	file_daedalus_proto_goTypes = nil
+	file_daedalus_proto_depIdxs = nil
+}
@@ -0,0 +1,249 @@
+// The Daedalus service lets Icarus solve laybrinths over gRPC.
+// It serves the same mazes as the HTTP API, from the same server.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: daedalus.proto
+
+package daedaluspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Daedalus_Awake_FullMethodName   = "/daedalus.Daedalus/Awake"
+	Daedalus_Move_FullMethodName    = "/daedalus.Daedalus/Move"
+	Daedalus_Done_FullMethodName    = "/daedalus.Daedalus/Done"
+	Daedalus_Explore_FullMethodName = "/daedalus.Daedalus/Explore"
+)
+
+// DaedalusClient is the client API for Daedalus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DaedalusClient interface {
+	// Awake starts a new maze, returning what Icarus sees when he wakes up.
+	Awake(ctx context.Context, in *AwakeRequest, opts ...grpc.CallOption) (*MoveReply, error)
+	// Move moves Icarus one room. Walking into a wall isn't an error;
+	// the reply says so, and Icarus stays where he was. Once the maze is
+	// over, because he found the treasure or ran out of steps or time,
+	// moves fail with FAILED_PRECONDITION.
+	Move(ctx context.Context, in *MoveRequest, opts ...grpc.CallOption) (*MoveReply, error)
+	// Done says Icarus has finished, and shuts the server down.
+	Done(ctx context.Context, in *DoneRequest, opts ...grpc.CallOption) (*DoneReply, error)
+	// Explore makes each move sent, answering each in turn,
+	// just as Move would.
+	Explore(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MoveRequest, MoveReply], error)
+}
+
+type daedalusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDaedalusClient(cc grpc.ClientConnInterface) DaedalusClient {
+	return &daedalusClient{cc}
+}
+
+func (c *daedalusClient) Awake(ctx context.Context, in *AwakeRequest, opts ...grpc.CallOption) (*MoveReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveReply)
+	err := c.cc.Invoke(ctx, Daedalus_Awake_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daedalusClient) Move(ctx context.Context, in *MoveRequest, opts ...grpc.CallOption) (*MoveReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveReply)
+	err := c.cc.Invoke(ctx, Daedalus_Move_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daedalusClient) Done(ctx context.Context, in *DoneRequest, opts ...grpc.CallOption) (*DoneReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DoneReply)
+	err := c.cc.Invoke(ctx, Daedalus_Done_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *daedalusClient) Explore(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MoveRequest, MoveReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Daedalus_ServiceDesc.Streams[0], Daedalus_Explore_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MoveRequest, MoveReply]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Daedalus_ExploreClient = grpc.BidiStreamingClient[MoveRequest, MoveReply]
+
+// DaedalusServer is the server API for Daedalus service.
+// All implementations must embed UnimplementedDaedalusServer
+// for forward compatibility.
+type DaedalusServer interface {
+	// Awake starts a new maze, returning what Icarus sees when he wakes up.
+	Awake(context.Context, *AwakeRequest) (*MoveReply, error)
+	// Move moves Icarus one room. Walking into a wall isn't an error;
+	// the reply says so, and Icarus stays where he was. Once the maze is
+	// over, because he found the treasure or ran out of steps or time,
+	// moves fail with FAILED_PRECONDITION.
+	Move(context.Context, *MoveRequest) (*MoveReply, error)
+	// Done says Icarus has finished, and shuts the server down.
+	Done(context.Context, *DoneRequest) (*DoneReply, error)
+	// Explore makes each move sent, answering each in turn,
+	// just as Move would.
+	Explore(grpc.BidiStreamingServer[MoveRequest, MoveReply]) error
+	mustEmbedUnimplementedDaedalusServer()
+}
+
+// UnimplementedDaedalusServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedDaedalusServer struct{}
+
+func (UnimplementedDaedalusServer) Awake(context.Context, *AwakeRequest) (*MoveReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Awake not implemented")
+}
+func (UnimplementedDaedalusServer) Move(context.Context, *MoveRequest) (*MoveReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Move not implemented")
+}
+func (UnimplementedDaedalusServer) Done(context.Context, *DoneRequest) (*DoneReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Done not implemented")
+}
+func (UnimplementedDaedalusServer) Explore(grpc.BidiStreamingServer[MoveRequest, MoveReply]) error {
+	return status.Errorf(codes.Unimplemented, "method Explore not implemented")
+}
+func (UnimplementedDaedalusServer) mustEmbedUnimplementedDaedalusServer() {}
+func (UnimplementedDaedalusServer) testEmbeddedByValue()                  {}
+
+// UnsafeDaedalusServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DaedalusServer will
+// result in compilation errors.
+type UnsafeDaedalusServer interface {
+	mustEmbedUnimplementedDaedalusServer()
+}
+
+func RegisterDaedalusServer(s grpc.ServiceRegistrar, srv DaedalusServer) {
+	// If the following call pancis, it indicates UnimplementedDaedalusServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Daedalus_ServiceDesc, srv)
+}
+
+func _Daedalus_Awake_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AwakeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaedalusServer).Awake(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daedalus_Awake_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaedalusServer).Awake(ctx, req.(*AwakeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daedalus_Move_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaedalusServer).Move(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daedalus_Move_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaedalusServer).Move(ctx, req.(*MoveRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daedalus_Done_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DaedalusServer).Done(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Daedalus_Done_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DaedalusServer).Done(ctx, req.(*DoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Daedalus_Explore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(DaedalusServer).Explore(&grpc.GenericServerStream[MoveRequest, MoveReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Daedalus_ExploreServer = grpc.BidiStreamingServer[MoveRequest, MoveReply]
+
+// Daedalus_ServiceDesc is the grpc.ServiceDesc for Daedalus service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Daedalus_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "daedalus.Daedalus",
+	HandlerType: (*DaedalusServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Awake",
+			Handler:    _Daedalus_Awake_Handler,
+		},
+		{
+			MethodName: "Move",
+			Handler:    _Daedalus_Move_Handler,
+		},
+		{
+			MethodName: "Done",
+			Handler:    _Daedalus_Done_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Explore",
+			Handler:       _Daedalus_Explore_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "daedalus.proto",
+}
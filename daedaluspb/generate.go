@@ -0,0 +1,4 @@
+// Package daedaluspb holds the gRPC API for daedalus, generated from daedalus.proto.
+package daedaluspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative daedalus.proto
@@ -0,0 +1,309 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RequestIDHeader carries a unique ID for each move, so that daedalus can
+// tell a retried move from a new one.
+const RequestIDHeader = "X-Request-ID"
+
+const (
+	defaultBackoff = 100 * time.Millisecond
+	maxBackoff     = 5 * time.Second
+	readyPoll      = 50 * time.Millisecond
+)
+
+// Client talks to a daedalus server over HTTP.
+// The zero value isn't usable; BaseURL must be set.
+//
+// If daedalus can't be reached, requests are retried up to Retries times,
+// waiting Backoff before the first retry and twice as long before each one
+// after that. Moves are sent with a request ID so that daedalus only makes
+// them once however many times they are retried.
+type Client struct {
+	BaseURL    string        // where daedalus is, e.g. "http://127.0.0.1:8013"
+	Host       string        // sent as the Host header instead of the one in BaseURL, if set
+	Timeout    time.Duration // for each request, if HTTPClient isn't set
+	HTTPClient *http.Client  // used to make requests, if set
+	Retries    int           // how many times to retry a failed request
+	Backoff    time.Duration // how long to wait before the first retry (default 100ms)
+}
+
+// NewClient returns a Client for the daedalus at baseURL.
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+// StatusError is returned when daedalus answers with anything but 200 OK.
+type StatusError struct {
+	StatusCode int
+	Message    string // from the reply, if there was one
+}
+
+func (e *StatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("daedalus: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("daedalus: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return &http.Client{Timeout: c.Timeout}
+}
+
+func (c *Client) send(method, path, id string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.BaseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.Host != "" {
+		req.Host = c.Host
+	}
+	if id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.httpClient().Do(req)
+}
+
+func (c *Client) once(path, id string) (*http.Response, error) {
+	return c.send("GET", path, id, nil)
+}
+
+// Make a request, retrying while daedalus can't be reached.
+// If retryFailures is set, it's safe to ask again when daedalus answers
+// with a server error too.
+func (c *Client) do(method, path, id string, body []byte, retryFailures bool) (*http.Response, error) {
+	backoff := c.Backoff
+	if backoff <= 0 {
+		backoff = defaultBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(method, path, id, body)
+		retry := err != nil || (retryFailures && resp.StatusCode >= 500)
+		if !retry || attempt >= c.Retries {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// Make a request and decode the reply
+func (c *Client) get(path, id string, retryFailures bool) (Reply, error) {
+	var rep Reply
+	resp, err := c.do("GET", path, id, nil, retryFailures)
+	if err != nil {
+		return rep, err
+	}
+	return rep, decode(resp, path, &rep)
+}
+
+// Decode a reply into v, or if it isn't a success, return it as a StatusError.
+// v may be nil if there's nothing to decode.
+func decode(resp *http.Response, path string, v interface{}) error {
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		return &StatusError{StatusCode: resp.StatusCode, Message: errorMessage(body)}
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("daedalus: bad reply to %s: %w", path, err)
+	}
+	return nil
+}
+
+// The message in an error reply: a v1 Reply's message, or a v2 ErrorReply's
+// error. It doesn't matter if there isn't one.
+func errorMessage(body []byte) string {
+	var rep struct {
+		Message string          `json:"message"`
+		Error   json.RawMessage `json:"error"`
+	}
+	json.Unmarshal(body, &rep)
+
+	var message string
+	if json.Unmarshal(rep.Error, &message) == nil && message != "" {
+		return message
+	}
+	return rep.Message
+}
+
+// A random ID for a move
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Ready checks that daedalus is up and answering requests.
+func (c *Client) Ready() error {
+	resp, err := c.once("/healthz", "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// WaitReady waits up to timeout for daedalus to be ready,
+// returning why it isn't if it never is.
+func (c *Client) WaitReady(timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := c.Ready()
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(readyPoll)
+	}
+}
+
+// Awake asks daedalus for a new maze, returning what Icarus sees when he wakes up.
+func (c *Client) Awake() (Survey, error) {
+	// Waking up again forfeits the last maze, so retries carry the same
+	// request ID, letting daedalus answer them with the maze it already made
+	rep, err := c.get("/awake", newRequestID(), true)
+	return rep.Survey, err
+}
+
+// The typed error for a move refused because the maze was over, or err
+// if that's not why it failed
+func overError(err error) error {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusGone {
+		if over := MazeOverError(statusErr.Message); over != nil {
+			return over
+		}
+	}
+	return err
+}
+
+// Move moves Icarus one room in the given direction, returning what he sees there.
+// It returns ErrVictory along with the survey once he finds the treasure,
+// ErrWall if there's a wall in the way, and ErrSolved, ErrOutOfSteps,
+// ErrOutOfTime or ErrForfeited if daedalus says the maze is over.
+func (c *Client) Move(dir Direction) (Survey, error) {
+	if !dir.Valid() {
+		return Survey{}, ErrInvalidDirection
+	}
+	rep, err := c.get("/move/"+dir.String(), newRequestID(), false)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusConflict {
+		return Survey{}, ErrWall
+	}
+	if err != nil {
+		return Survey{}, overError(err)
+	}
+	if rep.Victory {
+		return rep.Survey, ErrVictory
+	}
+	return rep.Survey, nil
+}
+
+// Moves makes each move in turn with a single request, stopping at the
+// first wall or the treasure. It returns what Icarus saw in each room he
+// moved into, along with ErrWall or ErrVictory if that's why he stopped.
+// Unlike Move, it isn't retried, as daedalus might make the moves twice.
+func (c *Client) Moves(dirs []Direction) ([]Survey, error) {
+	for _, dir := range dirs {
+		if !dir.Valid() {
+			return nil, ErrInvalidDirection
+		}
+	}
+	body, err := json.Marshal(MovesRequest{Directions: dirs})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send("POST", "/moves", "", body)
+	if err != nil {
+		return nil, err
+	}
+	var rep MovesReply
+	if err := decode(resp, "/moves", &rep); err != nil {
+		return nil, err
+	}
+	return rep.result()
+}
+
+// The surveys in a MovesReply, and why the moves stopped if they did
+func (rep MovesReply) result() ([]Survey, error) {
+	switch {
+	case rep.Victory:
+		return rep.Surveys, ErrVictory
+	case rep.Wall:
+		return rep.Surveys, ErrWall
+	case rep.Error:
+		if over := MazeOverError(rep.Message); over != nil {
+			return rep.Surveys, over
+		}
+		return rep.Surveys, errors.New(rep.Message)
+	}
+	return rep.Surveys, nil
+}
+
+// Done tells daedalus Icarus has finished solving mazes.
+func (c *Client) Done() error {
+	resp, err := c.once("/done", "")
+	if err != nil {
+		// Older versions of daedalus exit without answering
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &StatusError{StatusCode: resp.StatusCode}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package mazelib
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// A fake daedalus with a wall to the left and the treasure to the right
+func fakeDaedalus(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Host != "daedalus.example" {
+			t.Errorf("expected the Host header to be daedalus.example, got %q", r.Host)
+		}
+		switch r.URL.Path {
+		case "/awake":
+			fmt.Fprint(w, `{"survey":{"top":true,"left":true}}`)
+		case "/move/up":
+			fmt.Fprint(w, `{"survey":{"top":true}}`)
+		case "/move/right":
+			fmt.Fprint(w, `{"victory":true,"message":"Victory achieved in 2 steps"}`)
+		case "/move/left":
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"error":true,"message":"that's a wall"}`)
+		case "/move/down":
+			fmt.Fprint(w, `not json`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestClient(t *testing.T) {
+	server := fakeDaedalus(t)
+	defer server.Close()
+	c := NewClient(server.URL)
+	c.Host = "daedalus.example"
+
+	if s, err := c.Awake(); err != nil || !s.Top || !s.Left {
+		t.Errorf("Awake() = %v, %v", s, err)
+	}
+	if s, err := c.Move(N); err != nil || !s.Top {
+		t.Errorf("Move(N) = %v, %v", s, err)
+	}
+	if _, err := c.Move(E); err != ErrVictory {
+		t.Errorf("expected ErrVictory moving onto the treasure, got %v", err)
+	}
+
+	if _, err := c.Move(W); err != ErrWall {
+		t.Errorf("expected ErrWall moving into a wall, got %v", err)
+	}
+	if _, err := c.Move(Direction(0)); err != ErrInvalidDirection {
+		t.Errorf("expected ErrInvalidDirection, got %v", err)
+	}
+	if _, err := c.Move(S); err == nil {
+		t.Error("expected an error for a reply that isn't JSON")
+	}
+	var statusErr *StatusError
+	if err := c.Done(); !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("expected a 404 StatusError from Done, got %v", err)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	var awakes, moves int
+	var ids []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/awake":
+			// Fail once as though daedalus is still starting up
+			if awakes++; awakes == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"survey":{"top":true}}`)
+		case "/move/up":
+			ids = append(ids, r.Header.Get(RequestIDHeader))
+			// Drop the connection the first time, as though daedalus restarted
+			if moves++; moves == 1 {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			fmt.Fprint(w, `{}`)
+		}
+	}))
+	defer server.Close()
+	c := NewClient(server.URL)
+	c.Retries = 2
+	c.Backoff = time.Millisecond
+
+	if _, err := c.Awake(); err != nil || awakes != 2 {
+		t.Errorf("expected Awake to succeed on the second try, got %v after %d tries", err, awakes)
+	}
+	if _, err := c.Move(N); err != nil || moves != 2 {
+		t.Errorf("expected Move to succeed on the second try, got %v after %d tries", err, moves)
+	}
+	if len(ids) != 2 || ids[0] == "" || ids[0] != ids[1] {
+		t.Errorf("expected a retried move to keep its request ID, got %q", ids)
+	}
+
+	c.Move(N)
+	if len(ids) != 3 || ids[2] == ids[1] {
+		t.Errorf("expected a new move to get a new request ID, got %q", ids)
+	}
+
+	c.Retries = 0
+	server.Close()
+	if _, err := c.Awake(); err == nil {
+		t.Error("expected an error once daedalus is gone")
+	}
+}
+
+func TestClientWaitReady(t *testing.T) {
+	checks := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if checks++; checks < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer server.Close()
+	c := NewClient(server.URL)
+
+	if err := c.Ready(); err == nil {
+		t.Error("expected daedalus not to be ready at first")
+	}
+	if err := c.WaitReady(time.Second); err != nil || checks != 3 {
+		t.Errorf("expected daedalus to be ready on the third check, got %v after %d checks", err, checks)
+	}
+
+	server.Close()
+	if err := c.WaitReady(0); err == nil {
+		t.Error("expected an error once daedalus is gone")
+	}
+}
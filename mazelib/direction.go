@@ -0,0 +1,125 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Direction is one of the four ways Icarus can move
+type Direction int
+
+const (
+	N Direction = 1
+	S Direction = 2
+	E Direction = 3
+	W Direction = 4
+)
+
+var ErrInvalidDirection = errors.New("not a direction")
+
+// The names used for each direction in the daedalus API
+var directionNames = map[Direction]string{
+	N: "up",
+	S: "down",
+	E: "right",
+	W: "left",
+}
+
+// Valid reports whether d is one of N, S, E or W
+func (d Direction) Valid() bool {
+	_, ok := directionNames[d]
+	return ok
+}
+
+// Reverse returns the opposite direction
+func (d Direction) Reverse() Direction {
+	switch d {
+	case N:
+		return S
+	case S:
+		return N
+	case E:
+		return W
+	case W:
+		return E
+	}
+	panic("Not a direction")
+}
+
+// Delta returns how far a step in this direction moves Icarus
+func (d Direction) Delta() Coordinate {
+	switch d {
+	case N:
+		return Coordinate{X: 0, Y: -1}
+	case S:
+		return Coordinate{X: 0, Y: 1}
+	case E:
+		return Coordinate{X: 1, Y: 0}
+	case W:
+		return Coordinate{X: -1, Y: 0}
+	}
+	return Coordinate{}
+}
+
+// String returns the name daedalus uses for the direction: up, down, left or right
+func (d Direction) String() string {
+	if name, ok := directionNames[d]; ok {
+		return name
+	}
+	return fmt.Sprintf("Direction(%d)", int(d))
+}
+
+// ParseDirection reads a direction from its name as given by String,
+// or from a compass point such as "N" or "north".
+func ParseDirection(s string) (Direction, error) {
+	switch strings.ToLower(s) {
+	case "up", "n", "north":
+		return N, nil
+	case "down", "s", "south":
+		return S, nil
+	case "right", "e", "east":
+		return E, nil
+	case "left", "w", "west":
+		return W, nil
+	}
+	return 0, fmt.Errorf("%q is %w", s, ErrInvalidDirection)
+}
+
+// MarshalJSON writes the direction as its name
+func (d Direction) MarshalJSON() ([]byte, error) {
+	if !d.Valid() {
+		return nil, ErrInvalidDirection
+	}
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON reads a direction from anything ParseDirection accepts
+func (d *Direction) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	dir, err := ParseDirection(s)
+	if err != nil {
+		return err
+	}
+	*d = dir
+	return nil
+}
@@ -0,0 +1,40 @@
+package mazelib
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDirections(t *testing.T) {
+	for _, d := range []Direction{N, S, E, W} {
+		parsed, err := ParseDirection(d.String())
+		if err != nil || parsed != d {
+			t.Errorf("ParseDirection(%q) = %v, %v", d.String(), parsed, err)
+		}
+
+		back := d.Reverse().Delta()
+		if delta := d.Delta(); delta.X != -back.X || delta.Y != -back.Y {
+			t.Errorf("%v and its reverse don't cancel out", d)
+		}
+
+		b, err := json.Marshal(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var unmarshalled Direction
+		if err := json.Unmarshal(b, &unmarshalled); err != nil || unmarshalled != d {
+			t.Errorf("%v didn't survive JSON, got %v from %s", d, unmarshalled, b)
+		}
+	}
+
+	if d, err := ParseDirection("North"); err != nil || d != N {
+		t.Errorf("expected North to be N, got %v, %v", d, err)
+	}
+	if _, err := ParseDirection("sideways"); !errors.Is(err, ErrInvalidDirection) {
+		t.Errorf("expected ErrInvalidDirection, got %v", err)
+	}
+	if _, err := json.Marshal(Direction(0)); err == nil {
+		t.Error("expected an error marshalling an invalid direction")
+	}
+}
@@ -39,6 +39,37 @@ type Reply struct {
 	Error   bool   `json:"error"`
 }
 
+// MoveRequest is the body of a POST to a /v2 session's move
+type MoveRequest struct {
+	Direction Direction `json:"direction"`
+}
+
+// SessionReply answers the start of a /v2 session
+type SessionReply struct {
+	ID     string `json:"id"`
+	Survey Survey `json:"survey"`
+}
+
+// ErrorReply is the body of every error from the /v2 API
+type ErrorReply struct {
+	Error string `json:"error"`
+}
+
+// MovesRequest is the body of a POST to /moves
+type MovesRequest struct {
+	Directions []Direction `json:"directions"`
+}
+
+// MovesReply answers a MovesRequest with a survey for each move made.
+// The moves stop early at a wall or the treasure.
+type MovesReply struct {
+	Surveys []Survey `json:"surveys"`
+	Victory bool     `json:"victory"`
+	Wall    bool     `json:"wall"`
+	Message string   `json:"message"`
+	Error   bool     `json:"error"`
+}
+
 // Survey Given a location, survey surrounding locations
 // True indicates a wall is present.
 type Survey struct {
@@ -48,14 +79,30 @@ type Survey struct {
 	Left   bool `json:"left"`
 }
 
-const (
-	N = 1
-	S = 2
-	E = 3
-	W = 4
+var ErrVictory error = errors.New("Victory")
+
+// ErrWall is returned when Icarus tries to walk through a wall
+var ErrWall = errors.New("Can't walk through walls")
+
+// Daedalus won't let Icarus move once a maze is over, returning why it is
+var (
+	ErrSolved     = errors.New("Icarus has already found the treasure")
+	ErrOutOfSteps = errors.New("Icarus has run out of steps")
+	ErrOutOfTime  = errors.New("Icarus has run out of time")
+	ErrForfeited  = errors.New("Icarus has given up on this maze")
 )
 
-var ErrVictory error = errors.New("Victory")
+// MazeOverError returns the error daedalus refused a move with, given its
+// message, if the move was refused because the maze was over. Otherwise
+// it returns nil.
+func MazeOverError(message string) error {
+	for _, err := range []error{ErrSolved, ErrOutOfSteps, ErrOutOfTime, ErrForfeited} {
+		if message == err.Error() {
+			return err
+		}
+	}
+	return nil
+}
 
 // Room contains the minimum informaion about a room in the maze.
 type Room struct {
@@ -65,7 +112,7 @@ type Room struct {
 	Walls    Survey
 }
 
-func (r *Room) AddWall(dir int) {
+func (r *Room) AddWall(dir Direction) {
 	switch dir {
 	case N:
 		r.Walls.Top = true
@@ -78,7 +125,7 @@ func (r *Room) AddWall(dir int) {
 	}
 }
 
-func (r *Room) RmWall(dir int) {
+func (r *Room) RmWall(dir Direction) {
 	switch dir {
 	case N:
 		r.Walls.Top = false
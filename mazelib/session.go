@@ -0,0 +1,152 @@
+// Copyright © 2015 Steve Francia <spf@spf13.com>.
+//
+//   Licensed under the Apache License, Version 2.0 (the "License");
+//   you may not use this file except in compliance with the License.
+//   You may obtain a copy of the License at
+//
+//       http://www.apache.org/licenses/LICENSE-2.0
+//
+//   Unless required by applicable law or agreed to in writing, software
+//   distributed under the License is distributed on an "AS IS" BASIS,
+//   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//   See the License for the specific language governing permissions and
+//   limitations under the License.
+//
+
+package mazelib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrSessionNotFound is returned when daedalus doesn't know the session
+var ErrSessionNotFound = errors.New("No such session")
+
+// ErrSessionFinished is returned for moves in a session that has ended
+var ErrSessionFinished = errors.New("Session has finished")
+
+// Session is a maze of Icarus's own, from daedalus's /v2 API.
+// Unlike the v1 API, several sessions can be played at once.
+type Session struct {
+	ID     string
+	client *Client
+}
+
+// Turn the statuses the /v2 API uses for errors back into them
+func sessionError(err error) error {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return err
+	}
+	switch statusErr.StatusCode {
+	case http.StatusNotFound:
+		return ErrSessionNotFound
+	case http.StatusConflict:
+		return ErrWall
+	case http.StatusGone:
+		if over := MazeOverError(statusErr.Message); over != nil {
+			return fmt.Errorf("%w: %w", ErrSessionFinished, over)
+		}
+		if statusErr.Message != "" {
+			return fmt.Errorf("%w: %s", ErrSessionFinished, statusErr.Message)
+		}
+		return ErrSessionFinished
+	}
+	return err
+}
+
+// Start starts a session with a new maze, returning it along with what
+// Icarus sees when he wakes up.
+func (c *Client) Start() (*Session, Survey, error) {
+	// An unused session would be a forfeit, so retries carry the same
+	// request ID, letting daedalus answer them with the session it started
+	resp, err := c.do("POST", "/v2/sessions", newRequestID(), nil, true)
+	if err != nil {
+		return nil, Survey{}, err
+	}
+	var rep SessionReply
+	if err := decode(resp, "/v2/sessions", &rep); err != nil {
+		return nil, Survey{}, err
+	}
+	return &Session{ID: rep.ID, client: c}, rep.Survey, nil
+}
+
+func (s *Session) path() string {
+	return "/v2/sessions/" + s.ID
+}
+
+// Move moves Icarus just as Client.Move does. Once the session is over it
+// returns ErrSessionFinished, wrapping why it is, such as ErrOutOfTime.
+func (s *Session) Move(dir Direction) (Survey, error) {
+	if !dir.Valid() {
+		return Survey{}, ErrInvalidDirection
+	}
+	body, err := json.Marshal(MoveRequest{Direction: dir})
+	if err != nil {
+		return Survey{}, err
+	}
+
+	path := s.path() + "/move"
+	resp, err := s.client.do("POST", path, newRequestID(), body, false)
+	if err != nil {
+		return Survey{}, err
+	}
+	var rep Reply
+	if err := decode(resp, path, &rep); err != nil {
+		return Survey{}, sessionError(err)
+	}
+	if rep.Victory {
+		return rep.Survey, ErrVictory
+	}
+	return rep.Survey, nil
+}
+
+// Moves makes each move in turn with a single request, just as Client.Moves does.
+func (s *Session) Moves(dirs []Direction) ([]Survey, error) {
+	for _, dir := range dirs {
+		if !dir.Valid() {
+			return nil, ErrInvalidDirection
+		}
+	}
+	body, err := json.Marshal(MovesRequest{Directions: dirs})
+	if err != nil {
+		return nil, err
+	}
+
+	path := s.path() + "/moves"
+	resp, err := s.client.send("POST", path, "", body)
+	if err != nil {
+		return nil, err
+	}
+	var rep MovesReply
+	if err := decode(resp, path, &rep); err != nil {
+		return nil, sessionError(err)
+	}
+	return rep.result()
+}
+
+// End ends the session. Ending one that's already over, or already gone,
+// isn't an error.
+func (s *Session) End() error {
+	resp, err := s.client.do("DELETE", s.path(), "", nil, true)
+	if err != nil {
+		return err
+	}
+	err = sessionError(decode(resp, s.path(), nil))
+	if errors.Is(err, ErrSessionFinished) || err == ErrSessionNotFound {
+		return nil
+	}
+	return err
+}
+
+// Shutdown tells daedalus to print its results and stop.
+func (c *Client) Shutdown() error {
+	resp, err := c.send("POST", "/v2/done", "", nil)
+	if err != nil {
+		return err
+	}
+	return decode(resp, "/v2/done", nil)
+}
@@ -0,0 +1,49 @@
+package mazelib
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestStreamUsesClientSettings(t *testing.T) {
+	server := httptest.NewServer(websocket.Server{Handler: func(ws *websocket.Conn) {
+		if host := ws.Request().Host; host != "daedalus.example" {
+			t.Errorf("expected the Host header to be daedalus.example, got %q", host)
+		}
+		var move StreamMove
+		for websocket.JSON.Receive(ws, &move) == nil {
+			if move.Direction != E {
+				t.Errorf("expected to move right, got %v", move.Direction)
+			}
+			websocket.JSON.Send(ws, StreamReply{Reply: Reply{Survey: Survey{Top: true}}, Status: http.StatusOK})
+		}
+	}})
+	defer server.Close()
+
+	dials := 0
+	c := NewClient(server.URL)
+	c.Host = "daedalus.example"
+	c.HTTPClient = &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dials++
+			return (&net.Dialer{}).DialContext(ctx, network, addr)
+		},
+	}}
+
+	s, err := c.Stream()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if dials != 1 {
+		t.Errorf("expected the stream to be dialed with HTTPClient's transport, got %d dials", dials)
+	}
+	if survey, err := s.Move(E); err != nil || !survey.Top {
+		t.Errorf("expected a survey with a wall on top, got %v, %v", survey, err)
+	}
+}